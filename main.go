@@ -13,18 +13,17 @@ import (
 	"github.com/mitchellh/panicwrap"
 
 	log "github.com/sirupsen/logrus"
-	"go.mongodb.org/mongo-driver/bson"
 
 	"github.com/SevenTV/ServerGo/src/configure"
 	"github.com/SevenTV/ServerGo/src/discord"
 	"github.com/SevenTV/ServerGo/src/mongo"
-	"github.com/SevenTV/ServerGo/src/mongo/cache"
-	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
-	_ "github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/redis"
 	"github.com/SevenTV/ServerGo/src/server"
+	"github.com/SevenTV/ServerGo/src/server/health"
 
 	"github.com/SevenTV/ServerGo/src/server/api/actions"
 	"github.com/SevenTV/ServerGo/src/server/api/tasks"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/events"
 )
 
 func init() {
@@ -59,19 +58,7 @@ func main() {
 
 		start := time.Now().UnixNano()
 
-		// Run pre-shutdown cleanup
-		Cleanup()
-
-		wg := sync.WaitGroup{}
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			if err := s.Shutdown(); err != nil {
-				log.WithError(err).Error("failed to shutdown server")
-			}
-		}()
-		wg.Wait()
+		Shutdown(s)
 
 		log.WithField("duration", float64(time.Now().UnixNano()-start)/10e5).Infof("shutdown")
 		os.Exit(configCode)
@@ -90,48 +77,73 @@ func main() {
 
 	// Get and cache roles*
 	ctx := context.Background()
-	roles, err := GetAllRoles(ctx)
+	roles, err := actions.Roles.Fetch(ctx)
 	if err != nil {
 		log.WithError(err).Error("could not get roles")
 	}
 	log.WithField("count", len(roles)).Infof("retrieved roles")
+	if err := actions.Roles.SyncPositions(ctx); err != nil {
+		log.WithError(err).Error("could not sync role positions")
+	}
+	go actions.Roles.Listen(ctx)
 
 	// Sync bans
 	err = actions.Bans.FetchBans(ctx)
 	if err != nil {
 		log.WithError(err).Error("could not sync bans")
 	}
-	log.WithField("count", len(actions.Bans.BannedUsers)).Info("retrieved bans")
+	log.WithField("count", actions.Bans.Count()).Info("retrieved bans")
+	go actions.Bans.Listen(ctx)
 
 	go tasks.Start()
 
 	select {}
 }
 
-func Cleanup() {
-	// Cleanup ongoing tasks
-	tasks.Cleanup()
-
-	// Logout from discord
-	_ = discord.Discord.CloseWithCode(1000)
-}
-
-// Get all roles available and cache into the mongo context
-func GetAllRoles(ctx context.Context) ([]datastructure.Role, error) {
-	roles := []datastructure.Role{}
-	cur, err := mongo.Collection(mongo.CollectionNameRoles).Find(ctx, bson.M{})
-	if err != nil {
-		return nil, err
+// Shutdown stops the server in order, each step being given its own timeout so that one running late doesn't cut the
+// others short
+func Shutdown(s *server.Server) {
+	timeout := configure.Config.GetDuration("shutdown.timeout")
+	if timeout <= 0 {
+		timeout = time.Second * 30
 	}
+	step := func(name string, f func(ctx context.Context) error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
 
-	roles = append(roles, *datastructure.DefaultRole) // Add default role
-	if err := cur.All(ctx, &roles); err != nil {      // Fetch roles
-		return nil, err
+		if err := f(ctx); err != nil {
+			log.WithError(err).Errorf("failed to %s", name)
+		}
 	}
 
-	// Set "AllRoles" value to mongo context
-	cache.CachedRoles = roles
-	return roles, nil
+	// Stop receiving traffic from load balancers, and give them time to notice.
+	// Event streams stay open until clients disconnect, so they are ended right away for clients to reconnect elsewhere
+	health.Drain()
+	events.Close()
+	time.Sleep(configure.Config.GetDuration("shutdown.drain_delay"))
+
+	// Stop accepting connections and finish the requests in flight, while stopping background tasks and letting them
+	// finish their current work and release their locks
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		step("shutdown server", s.Shutdown)
+	}()
+	go func() {
+		defer wg.Done()
+		step("stop tasks", tasks.Cleanup)
+	}()
+	wg.Wait()
+
+	// Send the remaining webhooks and logout from discord
+	step("send discord webhooks", discord.Wait)
+	_ = discord.Discord.CloseWithCode(1000)
+
+	step("close mongo", mongo.Close)
+	if err := redis.Close(); err != nil {
+		log.WithError(err).Error("failed to close redis")
+	}
 }
 
 func panicHandler(output string) {
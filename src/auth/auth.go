@@ -96,3 +96,29 @@ func GetAuth(ctx context.Context) (string, error) {
 	}()
 	return auth, nil
 }
+
+// Validate checks that the app access token is still accepted by twitch
+func Validate(ctx context.Context) error {
+	token, err := GetAuth(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://id.twitch.tv/oauth2/validate", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "OAuth "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("twitch rejected the app access token (%d)", resp.StatusCode)
+	}
+
+	return nil
+}
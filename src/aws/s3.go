@@ -2,10 +2,9 @@ package aws
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 
-	"github.com/SevenTV/ServerGo/src/configure"
-
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -13,24 +12,37 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
-var sess = session.Must(session.NewSession(&aws.Config{
-	Credentials: credentials.NewStaticCredentials(configure.Config.GetString("aws_akid"), configure.Config.GetString("aws_secret_key"), configure.Config.GetString("aws_session_token")),
-	Region:      aws.String(configure.Config.GetString("aws_region")),
-	Endpoint:    aws.String(configure.Config.GetString("aws_endpoint")),
-}))
-
-var svc = s3.New(sess)
+// S3 stores files in an S3-compatible service
+type S3 struct {
+	svc        *s3.S3
+	uploader   *s3manager.Uploader
+	downloader *s3manager.Downloader
+}
 
-var uploader = s3manager.NewUploader(sess)
+// NewS3 creates a session with the credentials and region of the "aws_*" options of a config
+func NewS3(c *viper.Viper) (*S3, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials(c.GetString("aws_akid"), c.GetString("aws_secret_key"), c.GetString("aws_session_token")),
+		Region:      aws.String(c.GetString("aws_region")),
+		Endpoint:    aws.String(c.GetString("aws_endpoint")),
+	})
+	if err != nil {
+		return nil, err
+	}
 
-func UploadFile(bucket, key string, body []byte, contentType *string) error {
-	// The session the S3 Uploader will use
+	return &S3{
+		svc:        s3.New(sess),
+		uploader:   s3manager.NewUploader(sess),
+		downloader: s3manager.NewDownloader(sess),
+	}, nil
+}
 
-	// Create an uploader with the session and default options
+func (s *S3) UploadFile(bucket, key string, body []byte, contentType *string) error {
 	// Upload the file to S3.
-	result, err := uploader.Upload(&s3manager.UploadInput{
+	result, err := s.uploader.Upload(&s3manager.UploadInput{
 		Bucket:       aws.String(bucket),
 		Key:          aws.String(key),
 		Body:         bytes.NewReader(body),
@@ -45,11 +57,32 @@ func UploadFile(bucket, key string, body []byte, contentType *string) error {
 	return nil
 }
 
-func Expire(bucket, key string, number int) error {
+func (s *S3) UploadPrivateFile(bucket, key string, body []byte) error {
+	_, err := s.uploader.Upload(&s3manager.UploadInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+		ACL:    aws.String("private"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload file, %v", err)
+	}
+	return nil
+}
+
+func (s *S3) DownloadFile(bucket, key string) ([]byte, error) {
+	buf := aws.NewWriteAtBuffer([]byte{})
+	if _, err := s.downloader.Download(buf, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)}); err != nil {
+		return nil, fmt.Errorf("unable to download object %q from bucket %q, %v", key, bucket, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *S3) Expire(bucket, key string, number int) error {
 	obj := fmt.Sprintf("deleted/%s/%vx", key, number)
 
 	sourceObject := fmt.Sprintf("%s/%s/%vx", bucket, key, number)
-	_, err := svc.CopyObject(&s3.CopyObjectInput{
+	_, err := s.svc.CopyObject(&s3.CopyObjectInput{
 		ACL:        aws.String("private"),
 		Bucket:     aws.String(bucket),
 		CopySource: aws.String(sourceObject),
@@ -60,19 +93,19 @@ func Expire(bucket, key string, number int) error {
 		return fmt.Errorf("unable to expire object %q from bucket %q, %v", key, bucket, err)
 	}
 
-	err = svc.WaitUntilObjectExists(&s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(obj)})
+	err = s.svc.WaitUntilObjectExists(&s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(obj)})
 	if err != nil {
 		return fmt.Errorf("unable to expire object %q from bucket %q, %v", key, bucket, err)
 	}
 
-	return DeleteFile(bucket, fmt.Sprintf("%s/%vx", key, number), false)
+	return s.DeleteFile(bucket, fmt.Sprintf("%s/%vx", key, number), false)
 }
 
-func Unexpire(bucket, key string, number int) error {
+func (s *S3) Unexpire(bucket, key string, number int) error {
 	obj := fmt.Sprintf("%s/%vx", key, number)
 
 	sourceObject := fmt.Sprintf("%s/deleted/%s/%vx", bucket, key, number)
-	_, err := svc.CopyObject(&s3.CopyObjectInput{
+	_, err := s.svc.CopyObject(&s3.CopyObjectInput{
 		Bucket:     aws.String(bucket),
 		CopySource: aws.String(sourceObject),
 		Key:        aws.String(obj),
@@ -82,24 +115,32 @@ func Unexpire(bucket, key string, number int) error {
 		return fmt.Errorf("unable to expire object %q from bucket %q, %v", key, bucket, err)
 	}
 
-	err = svc.WaitUntilObjectExists(&s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(obj)})
+	err = s.svc.WaitUntilObjectExists(&s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(obj)})
 	if err != nil {
 		return fmt.Errorf("unable to expire object %q from bucket %q, %v", key, bucket, err)
 	}
 
-	return DeleteFile(bucket, fmt.Sprintf("deleted/%s/%vx", key, number), false)
+	return s.DeleteFile(bucket, fmt.Sprintf("deleted/%s/%vx", key, number), false)
 }
 
-func DeleteFile(bucket, key string, wait bool) error {
-	_, err := svc.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+func (s *S3) DeleteFile(bucket, key string, wait bool) error {
+	_, err := s.svc.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
 	if err != nil {
 		return fmt.Errorf("unable to delete object %q from bucket %q, %v", key, bucket, err)
 	}
 	if wait {
-		return svc.WaitUntilObjectNotExists(&s3.HeadObjectInput{
+		return s.svc.WaitUntilObjectNotExists(&s3.HeadObjectInput{
 			Bucket: aws.String(bucket),
 			Key:    aws.String(key),
 		})
 	}
 	return nil
 }
+
+func (s *S3) Ping(ctx context.Context, bucket string) error {
+	if _, err := s.svc.HeadBucketWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}); err != nil {
+		return fmt.Errorf("unable to reach bucket %q, %v", bucket, err)
+	}
+
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/SevenTV/ServerGo/src/cache/decoder"
 	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/metrics"
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/redis"
 	"github.com/SevenTV/ServerGo/src/utils"
@@ -114,6 +115,7 @@ func Find(ctx context.Context, collection, commonIndex string, q interface{}, ou
 		if err != redis.ErrNil {
 			log.WithError(err).Error("redis")
 		}
+		metrics.CacheRequests.WithLabelValues(collection, "miss").Inc()
 		// MongoQuery
 		cur, err := mongo.Database.Collection(collection).Find(ctx, q, opts...)
 		if err != nil {
@@ -146,6 +148,7 @@ func Find(ctx context.Context, collection, commonIndex string, q interface{}, ou
 
 		return decoder.Decode(out, output)
 	} else if len(missingIDs) > 0 {
+		metrics.CacheRequests.WithLabelValues(collection, "partial").Inc()
 		cur, err := mongo.Database.Collection(collection).Find(ctx, bson.M{
 			"_id": bson.M{
 				"$in": missingIDs,
@@ -181,6 +184,8 @@ func Find(ctx context.Context, collection, commonIndex string, q interface{}, ou
 		if err = decoder.Decode(results, output); err != nil {
 			return err
 		}
+	} else {
+		metrics.CacheRequests.WithLabelValues(collection, "hit").Inc()
 	}
 
 	return decoder.Decode(val, output)
@@ -211,6 +216,7 @@ func FindOne(ctx context.Context, collection, commonIndex string, q interface{},
 		if err != redis.ErrNil {
 			log.WithError(err).Error("redis")
 		}
+		metrics.CacheRequests.WithLabelValues(collection, "miss").Inc()
 		// MongoQuery
 		res := mongo.Database.Collection(collection).FindOne(ctx, q, opts...)
 		err = res.Err()
@@ -240,6 +246,7 @@ func FindOne(ctx context.Context, collection, commonIndex string, q interface{},
 
 		return decoder.Decode(out, output)
 	}
+	metrics.CacheRequests.WithLabelValues(collection, "hit").Inc()
 	return decoder.Decode(val[0], output)
 }
 
@@ -0,0 +1,240 @@
+package configure
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var twitchLoginRegex = regexp.MustCompile(`^[a-zA-Z0-9_]{0,25}$`)
+
+// Map keys are named as "parent[key]" by the decoder, while list indices stay as "list[0]"
+var decodedMapKeyRegex = regexp.MustCompile(`\[([^\]]*[^0-9\]][^\]]*)\]`)
+
+// Decoding errors quote the key they are about, such as "cannot parse 'key' as int"
+var decodeErrorRegex = regexp.MustCompile(`^(.*?)'([^']*)' ?(.*)$`)
+
+// Check decodes a config into its typed model and validates it, returning every problem as "<key>: <problem>".
+// Keys which aren't part of the model are returned apart, as they are likely typos but don't stop the server
+func Check(c *viper.Viper) (problems []string, unknownKeys []string) {
+	cfg := ServerCfg{}
+	md := mapstructure.Metadata{}
+	if err := c.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) {
+		dc.Metadata = &md
+	}); err != nil {
+		errs := []string{err.Error()}
+		if e, ok := err.(*mapstructure.Error); ok {
+			errs = e.Errors
+		}
+		for _, e := range errs {
+			// Lead with the key, as the other problems do
+			if m := decodeErrorRegex.FindStringSubmatch(e); m != nil {
+				e = fmt.Sprintf("%s: %s", decodedMapKeyRegex.ReplaceAllString(m[2], ".$1"), strings.TrimSpace(m[1]+m[3]))
+			}
+			problems = append(problems, e)
+		}
+	}
+
+	problems = append(problems, cfg.validate()...)
+	for _, key := range md.Unused {
+		unknownKeys = append(unknownKeys, decodedMapKeyRegex.ReplaceAllString(key, ".$1"))
+	}
+
+	sort.Strings(problems)
+	sort.Strings(unknownKeys)
+	return problems, unknownKeys
+}
+
+// Check the values of the options, beyond their types
+func (cfg *ServerCfg) validate() []string {
+	problems := []string{}
+	problem := func(key string, format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", key, fmt.Sprintf(format, a...)))
+	}
+	required := func(key string, value string) bool {
+		if value == "" {
+			problem(key, "required")
+		}
+		return value != ""
+	}
+	oneOf := func(key string, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		problem(key, "must be one of %s, not %q", strings.Join(allowed, ", "), value)
+	}
+	urlWithScheme := func(key string, value string, schemes ...string) {
+		u, err := url.Parse(value)
+		if err != nil {
+			problem(key, "invalid url: %v", err)
+			return
+		}
+		for _, s := range schemes {
+			if u.Scheme == s {
+				return
+			}
+		}
+		problem(key, "must be a %s url", strings.Join(schemes, " or "))
+	}
+	notNegative := func(key string, value int64) {
+		if value < 0 {
+			problem(key, "must not be negative")
+		}
+	}
+	notNegativeDuration := func(key string, value time.Duration) {
+		if value < 0 {
+			problem(key, "must not be negative")
+		}
+	}
+
+	if cfg.Level != "" {
+		if _, err := log.ParseLevel(cfg.Level); err != nil {
+			problem("level", "%v", err)
+		}
+	}
+
+	// Connections
+	if required("redis_uri", cfg.RedisURI) {
+		urlWithScheme("redis_uri", cfg.RedisURI, "redis", "rediss", "unix")
+	}
+	notNegative("redis_db", int64(cfg.RedisDB))
+	if required("mongo_uri", cfg.MongoURI) {
+		urlWithScheme("mongo_uri", cfg.MongoURI, "mongodb", "mongodb+srv")
+	}
+	required("mongo_db", cfg.MongoDB)
+	required("conn_uri", cfg.ConnURI)
+	if required("conn_type", cfg.ConnType) {
+		oneOf("conn_type", cfg.ConnType, "tcp", "tcp4", "tcp6", "unix")
+	}
+	if cfg.InternalConnURI != "" && cfg.InternalConnURI == cfg.ConnURI {
+		problem("internal_conn_uri", "must differ from conn_uri")
+	}
+	if cfg.WebsiteURL != "" {
+		urlWithScheme("website_url", cfg.WebsiteURL, "http", "https")
+	}
+	if cfg.CdnURL != "" {
+		urlWithScheme("cdn_url", cfg.CdnURL, "http", "https")
+	}
+	required("jwt_secret", cfg.JWTSecret)
+	if cfg.ExitCode < 0 || cfg.ExitCode > 125 {
+		problem("exit_code", "must be within 0-125")
+	}
+
+	notNegative("websocket.heartbeat_interval", int64(cfg.Websocket.HeartbeatInterval))
+	notNegative("websocket.max_channels", int64(cfg.Websocket.MaxChannels))
+
+	// Emotes
+	required("temp_file_store", cfg.TempFileStore)
+	if cfg.EmoteDedup.Mode != "" {
+		oneOf("emote_dedup.mode", cfg.EmoteDedup.Mode, "off", "warn", "reject")
+	}
+	notNegative("emote_dedup.max_distance", int64(cfg.EmoteDedup.MaxDistance))
+	notNegative("emote_processing.workers", int64(cfg.EmoteProcessing.Workers))
+	notNegativeDuration("bans.sync_interval", cfg.Bans.SyncInterval)
+
+	// Limits
+	notNegative("limits.meta.channel_emote_slots", int64(cfg.Limits.Meta.ChannelEmoteSlots))
+	notNegative("limits.meta.emote_sets", int64(cfg.Limits.Meta.EmoteSets))
+	notNegative("limits.meta.access_tokens", cfg.Limits.Meta.AccessTokens)
+	notNegative("limits.meta.bulk_channel_emotes", int64(cfg.Limits.Meta.BulkChannelEmotes))
+	for _, tag := range []string{"gql", "emote-create"} {
+		if _, ok := cfg.Limits.Route[tag]; !ok {
+			problem("limits.route."+tag, "required")
+		}
+	}
+	for tag, rl := range cfg.Limits.Route {
+		if len(rl) != 2 || rl[0] <= 0 || rl[1] <= 0 {
+			problem("limits.route."+tag, "must be [limit, duration in milliseconds], both positive")
+		}
+	}
+	notNegative("limits.gql_cost.anonymous", cfg.Limits.GqlCost.Anonymous)
+	notNegative("limits.gql_cost.authenticated", cfg.Limits.GqlCost.Authenticated)
+	for id, budget := range cfg.Limits.GqlCost.Roles {
+		notNegative("limits.gql_cost.roles."+id, budget)
+	}
+	quota := cfg.Limits.UploadQuota
+	for key, limit := range map[string]int32{"daily": quota.Daily, "owned": quota.Owned} {
+		if limit < -1 {
+			problem("limits.upload_quota."+key, "must be -1 or more")
+		}
+	}
+	for group, overrides := range map[string]map[string]UploadQuotaOverride{"roles": quota.Roles, "subscriptions": quota.Subscriptions} {
+		for id, o := range overrides {
+			for key, limit := range map[string]*int32{"daily": o.Daily, "owned": o.Owned} {
+				if limit != nil && *limit < -1 {
+					problem(fmt.Sprintf("limits.upload_quota.%s.%s.%s", group, id, key), "must be -1 or more")
+				}
+			}
+		}
+	}
+
+	// Rate limits
+	for name, policy := range cfg.RateLimits.Policies {
+		tiers := map[string]RateLimitTier{"default": policy.Default}
+		for id, t := range policy.Roles {
+			tiers["roles."+id] = t
+		}
+		for id, t := range policy.Tokens {
+			tiers["tokens."+id] = t
+		}
+		for tierName, t := range tiers {
+			for windowName, w := range map[string]*RateLimitWindow{"burst": t.Burst, "sustained": t.Sustained} {
+				if w != nil && (w.Limit <= 0 || w.Period <= 0) {
+					problem(fmt.Sprintf("rate_limits.policies.%s.%s.%s", name, tierName, windowName), "limit and period must be positive")
+				}
+			}
+		}
+	}
+	if share := cfg.RateLimits.Local.Share; share < 0 || share > 1 {
+		problem("rate_limits.local.share", "must be within 0-1")
+	}
+	notNegativeDuration("rate_limits.local.probe_interval", cfg.RateLimits.Local.ProbeInterval)
+
+	notNegativeDuration("gql.persisted_queries.ttl", cfg.Gql.PersistedQueries.TTL)
+	notNegative("gql.persisted_queries.max_size", int64(cfg.Gql.PersistedQueries.MaxSize))
+	notNegative("gql.max_parallelism", int64(cfg.Gql.MaxParallelism))
+	notNegativeDuration("dataloader.wait", cfg.Dataloader.Wait)
+	notNegative("dataloader.max_batch", int64(cfg.Dataloader.MaxBatch))
+	notNegativeDuration("health.interval", cfg.Health.Interval)
+	notNegativeDuration("health.timeout", cfg.Health.Timeout)
+	notNegativeDuration("shutdown.drain_delay", cfg.Shutdown.DrainDelay)
+	notNegativeDuration("shutdown.timeout", cfg.Shutdown.Timeout)
+
+	if cfg.Storage.Backend != "" {
+		oneOf("storage.backend", cfg.Storage.Backend, "s3", "local")
+	}
+
+	// Integrations
+	if !twitchLoginRegex.MatchString(cfg.FeaturedBroadcast) {
+		problem("featured_broadcast", "must be a twitch login")
+	}
+	for key, wh := range map[string][]string{"activity": cfg.Discord.Webhooks.Activity, "alerts": cfg.Discord.Webhooks.Alerts} {
+		if len(wh) != 0 && len(wh) != 2 {
+			problem("discord.webhooks."+key, "must be [id, token]")
+		}
+	}
+	if (len(cfg.Chatterino.Stable) > 0 || len(cfg.Chatterino.Beta) > 0) && cfg.Chatterino.Version == "" {
+		problem("chatterino.version", "required with releases")
+	}
+	platformIDs := map[string]bool{}
+	for i, p := range cfg.Platforms {
+		key := fmt.Sprintf("platforms[%d]", i)
+		if p.ID == "" {
+			problem(key+".id", "required")
+		} else if platformIDs[p.ID] {
+			problem(key+".id", "%q is used by another platform", p.ID)
+		}
+		platformIDs[p.ID] = true
+	}
+
+	return problems
+}
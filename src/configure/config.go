@@ -4,62 +4,25 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
-	"github.com/fsnotify/fsnotify"
-	jsoniter "github.com/json-iterator/go"
 	"github.com/kr/pretty"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
-var json = jsoniter.ConfigCompatibleWithStandardLibrary
-
-type ServerCfg struct {
-	Level      string `mapstructure:"level" json:"level"`
-	ConfigFile string `mapstructure:"config_file" json:"config_file"`
-
-	RedisURI string `mapstructure:"redis_uri" json:"redis_uri"`
-	MongoURI string `mapstructure:"mongo_uri" json:"mongo_uri"`
-	MongoDB  string `mapstructure:"mongo_db" json:"mongo_db"`
-
-	ConnURI  string `mapstructure:"conn_uri" json:"conn_uri"`
-	ConnType string `mapstructure:"conn_type" json:"conn_type"`
-
-	WebsiteURL   string `mapstructure:"website_url" json:"website_url"`
-	CookieDomain string `mapstructure:"cookie_domain" json:"cookie_domain"`
-	CookieSecure string `mapstructure:"cookie_secure" json:"cookie_secure"`
-
-	TwitchClientID     string `mapstructure:"twitch_client_id" json:"twitch_client_id"`
-	TwitchRedirectURI  string `mapstructure:"twitch_redirect_uri" json:"twitch_redirect_uri"`
-	TwitchClientSecret string `mapstructure:"twitch_client_secret" json:"twitch_client_secret"`
-
-	TempFileStore string `mapstructure:"temp_file_store" json:"temp_file_store"`
-
-	JWTSecret string `mapstructure:"jwt_secret" json:"jwt_secret"`
-
-	AwsAKID      string `mapstructure:"aws_akid" json:"aws_akid"`
-	AwsToken     string `mapstructure:"aws_session_token" json:"aws_session_token"`
-	AwsSecretKey string `mapstructure:"aws_secret_key" json:"aws_secret_key"`
-	AwsCDNBucket string `mapstructure:"aws_cdn_bucket" json:"aws_cdn_bucket"`
-	AwsRegion    string `mapstructure:"aws_region" json:"aws_region"`
-
-	NodeID string `mapstructure:"node_id" json:"node_id"`
-
-	DisableRedisCache bool `mapstructure:"disable_redis_cache" json:"disable_redis_cache"`
-
-	GqlSniffer string `mapstructure:"gql_sniffer" json:"gql_sniffer"`
-
-	ExitCode int `mapstructure:"exit_code" json:"exit_code"`
-}
-
 // default config
-var defaultConf = ServerCfg{
-	ConfigFile: "config.yaml",
+var defaultConf = map[string]interface{}{
+	"config_file": "config.yaml",
 }
 
-var Config = viper.New()
+// The config in use, built from the defaults, the config file, and the flags and environment overriding it
+var Config = &LiveConfig{}
+
+// The path of the config file
+var configFile string
 
 // Capture environment variables
 var NodeName string = os.Getenv("NODE_NAME")
@@ -73,6 +36,40 @@ func initLog() {
 	}
 }
 
+// Bind the flags and the environment to a config, which both take precedence over its file
+func bindOverrides(c *viper.Viper) error {
+	if err := c.BindPFlags(pflag.CommandLine); err != nil {
+		return err
+	}
+
+	replacer := strings.NewReplacer(".", "_")
+	c.SetEnvKeyReplacer(replacer)
+	c.AllowEmptyEnv(true)
+	c.AutomaticEnv()
+	return nil
+}
+
+// Build a config from the defaults, the contents of a config file if any, and the flags and environment
+func newConfig(file string, b []byte) (*viper.Viper, error) {
+	c := viper.New()
+	if err := c.MergeConfigMap(defaultConf); err != nil {
+		return nil, err
+	}
+	if err := bindOverrides(c); err != nil {
+		return nil, err
+	}
+
+	if b != nil {
+		c.SetConfigFile(file)
+		c.SetConfigType(strings.TrimPrefix(filepath.Ext(file), "."))
+		if err := c.MergeConfig(bytes.NewReader(b)); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
 func checkErr(err error) {
 	if err != nil {
 		log.WithError(err).Fatal("config")
@@ -88,12 +85,6 @@ func init() {
 	}
 
 	log.SetFormatter(&log.JSONFormatter{})
-	// Default config
-	b, _ := json.Marshal(defaultConf)
-	defaultConfig := bytes.NewReader(b)
-	viper.SetConfigType("json")
-	checkErr(viper.ReadConfig(defaultConfig))
-	checkErr(Config.MergeConfigMap(viper.AllSettings()))
 
 	// Flags
 	pflag.String("config_file", "config.yaml", "configure filename")
@@ -131,36 +122,59 @@ func init() {
 
 	pflag.String("version", "1.0", "Version of the system.")
 	pflag.Int("exit_code", 0, "Status code for successful and graceful shutdown, [0-125].")
+	pflag.Bool("check-config", false, "Check the config for problems and exit, without connecting to anything.")
 	pflag.Parse()
-	checkErr(Config.BindPFlags(pflag.CommandLine))
 
-	// File
-	Config.SetConfigFile(Config.GetString("config_file"))
-	Config.AddConfigPath(".")
-	err := Config.ReadInConfig()
+	// File, as named by the defaults, flags or environment
+	c, err := newConfig("", nil)
+	checkErr(err)
+	configFile = c.GetString("config_file")
+	b, err := os.ReadFile(configFile)
 	if err != nil {
 		log.Warning(err)
 		log.Info("Using default config")
-	} else {
-		checkErr(Config.MergeInConfig())
 	}
-
-	// Environment
-	replacer := strings.NewReplacer(".", "_")
-	Config.SetEnvKeyReplacer(replacer)
-	Config.AllowEmptyEnv(true)
-	Config.AutomaticEnv()
+	c, err = newConfig(configFile, b)
+	checkErr(err)
+	Config.set(c)
 
 	// Log
 	initLog()
 
-	// Print final config
-	c := ServerCfg{}
-	checkErr(Config.Unmarshal(&c))
-	log.Debugf("Current configurations: \n%# v", pretty.Formatter(c))
+	// Check every option before anything connects, reporting all problems at once
+	problems, unknownKeys := Check(c)
+	if Config.GetBool("check-config") {
+		for _, key := range unknownKeys {
+			fmt.Printf("warning: %s: unknown key\n", key)
+		}
+		for _, p := range problems {
+			fmt.Printf("error: %s\n", p)
+		}
+		if len(problems) > 0 {
+			fmt.Printf("%s: %d problem(s)\n", Config.GetString("config_file"), len(problems))
+			os.Exit(1)
+		}
+		fmt.Printf("%s: ok\n", Config.GetString("config_file"))
+		os.Exit(0)
+	}
+	for _, key := range unknownKeys {
+		log.WithField("key", key).Warn("config, unknown key")
+	}
+	if len(problems) > 0 {
+		log.WithField("problems", problems).Fatal("config, invalid")
+	}
 
-	Config.WatchConfig()
-	Config.OnConfigChange(func(_ fsnotify.Event) {
-		fmt.Println("Config has changed")
+	// Print final config
+	cfg := ServerCfg{}
+	checkErr(c.Unmarshal(&cfg))
+	log.Debugf("Current configurations: \n%# v", pretty.Formatter(cfg))
+
+	// Apply changes to the config file without a restart
+	Subscribe(Subscription{
+		Keys:     []string{"level"},
+		OnChange: initLog,
 	})
+	if b != nil {
+		watch()
+	}
 }
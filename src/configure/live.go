@@ -0,0 +1,71 @@
+package configure
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// LiveConfig reads the config in use. Reloading publishes a new, checked instance instead of changing the one
+// being read, so a reload is applied all at once and never while a value is being read
+type LiveConfig struct {
+	v atomic.Value
+}
+
+// Viper returns the instance in use, which must not be changed
+func (c *LiveConfig) Viper() *viper.Viper {
+	return c.v.Load().(*viper.Viper)
+}
+
+func (c *LiveConfig) set(v *viper.Viper) {
+	c.v.Store(v)
+}
+
+func (c *LiveConfig) Get(key string) interface{} {
+	return c.Viper().Get(key)
+}
+
+func (c *LiveConfig) GetString(key string) string {
+	return c.Viper().GetString(key)
+}
+
+func (c *LiveConfig) GetBool(key string) bool {
+	return c.Viper().GetBool(key)
+}
+
+func (c *LiveConfig) GetInt(key string) int {
+	return c.Viper().GetInt(key)
+}
+
+func (c *LiveConfig) GetInt32(key string) int32 {
+	return c.Viper().GetInt32(key)
+}
+
+func (c *LiveConfig) GetInt64(key string) int64 {
+	return c.Viper().GetInt64(key)
+}
+
+func (c *LiveConfig) GetFloat64(key string) float64 {
+	return c.Viper().GetFloat64(key)
+}
+
+func (c *LiveConfig) GetDuration(key string) time.Duration {
+	return c.Viper().GetDuration(key)
+}
+
+func (c *LiveConfig) GetIntSlice(key string) []int {
+	return c.Viper().GetIntSlice(key)
+}
+
+func (c *LiveConfig) GetStringSlice(key string) []string {
+	return c.Viper().GetStringSlice(key)
+}
+
+func (c *LiveConfig) IsSet(key string) bool {
+	return c.Viper().IsSet(key)
+}
+
+func (c *LiveConfig) UnmarshalKey(key string, rawVal interface{}, opts ...viper.DecoderConfigOption) error {
+	return c.Viper().UnmarshalKey(key, rawVal, opts...)
+}
@@ -0,0 +1,237 @@
+package configure
+
+import "time"
+
+// ServerCfg models every option of the config, as set by the config file, flags and environment
+type ServerCfg struct {
+	Level       string `mapstructure:"level" json:"level"`
+	ConfigFile  string `mapstructure:"config_file" json:"config_file"`
+	CheckConfig bool   `mapstructure:"check-config" json:"check-config"`
+	Version     string `mapstructure:"version" json:"version"`
+
+	RedisURI    string `mapstructure:"redis_uri" json:"redis_uri"`
+	RedisDB     int    `mapstructure:"redis_db" json:"redis_db"`
+	MongoURI    string `mapstructure:"mongo_uri" json:"mongo_uri"`
+	MongoDB     string `mapstructure:"mongo_db" json:"mongo_db"`
+	MongoDirect bool   `mapstructure:"mongo_direct" json:"mongo_direct"`
+
+	ConnURI  string `mapstructure:"conn_uri" json:"conn_uri"`
+	ConnType string `mapstructure:"conn_type" json:"conn_type"`
+	// The address of the listener for metrics and other internal routes, which must not be exposed publicly
+	InternalConnURI string `mapstructure:"internal_conn_uri" json:"internal_conn_uri"`
+
+	WebsiteURL   string   `mapstructure:"website_url" json:"website_url"`
+	CdnURL       string   `mapstructure:"cdn_url" json:"cdn_url"`
+	CookieDomain string   `mapstructure:"cookie_domain" json:"cookie_domain"`
+	CookieSecure bool     `mapstructure:"cookie_secure" json:"cookie_secure"`
+	CorsOrigins  []string `mapstructure:"cors_origins" json:"cors_origins"`
+	CorsWildcard bool     `mapstructure:"cors_wildcard" json:"cors_wildcard"`
+
+	Websocket WebsocketCfg `mapstructure:"websocket" json:"websocket"`
+
+	TwitchClientID     string `mapstructure:"twitch_client_id" json:"twitch_client_id"`
+	TwitchRedirectURI  string `mapstructure:"twitch_redirect_uri" json:"twitch_redirect_uri"`
+	TwitchClientSecret string `mapstructure:"twitch_client_secret" json:"twitch_client_secret"`
+
+	TempFileStore   string             `mapstructure:"temp_file_store" json:"temp_file_store"`
+	EmoteDedup      EmoteDedupCfg      `mapstructure:"emote_dedup" json:"emote_dedup"`
+	EmoteProcessing EmoteProcessingCfg `mapstructure:"emote_processing" json:"emote_processing"`
+	Bans            BansCfg            `mapstructure:"bans" json:"bans"`
+
+	JWTSecret          string `mapstructure:"jwt_secret" json:"jwt_secret"`
+	DefaultPermissions int64  `mapstructure:"default_permissions" json:"default_permissions"`
+
+	Limits     LimitsCfg     `mapstructure:"limits" json:"limits"`
+	RateLimits RateLimitsCfg `mapstructure:"rate_limits" json:"rate_limits"`
+	Gql        GqlCfg        `mapstructure:"gql" json:"gql"`
+	Dataloader DataloaderCfg `mapstructure:"dataloader" json:"dataloader"`
+
+	AwsAKID      string `mapstructure:"aws_akid" json:"aws_akid"`
+	AwsToken     string `mapstructure:"aws_session_token" json:"aws_session_token"`
+	AwsSecretKey string `mapstructure:"aws_secret_key" json:"aws_secret_key"`
+	AwsCDNBucket string `mapstructure:"aws_cdn_bucket" json:"aws_cdn_bucket"`
+	AwsRegion    string `mapstructure:"aws_region" json:"aws_region"`
+	AwsEndpoint  string `mapstructure:"aws_endpoint" json:"aws_endpoint"`
+
+	Health   HealthCfg   `mapstructure:"health" json:"health"`
+	Shutdown ShutdownCfg `mapstructure:"shutdown" json:"shutdown"`
+	Storage  StorageCfg  `mapstructure:"storage" json:"storage"`
+
+	FeaturedBroadcast string        `mapstructure:"featured_broadcast" json:"featured_broadcast"`
+	Discord           DiscordCfg    `mapstructure:"discord" json:"discord"`
+	Chatterino        ChatterinoCfg `mapstructure:"chatterino" json:"chatterino"`
+	Google            GoogleCfg     `mapstructure:"google" json:"google"`
+	Platforms         []Platform    `mapstructure:"platforms" json:"platforms"`
+
+	NodeID string `mapstructure:"node_id" json:"node_id"`
+
+	DisableRedisCache bool `mapstructure:"disable_redis_cache" json:"disable_redis_cache"`
+
+	GqlSniffer string `mapstructure:"gql_sniffer" json:"gql_sniffer"`
+
+	ExitCode int `mapstructure:"exit_code" json:"exit_code"`
+}
+
+type WebsocketCfg struct {
+	Enabled bool `mapstructure:"enabled" json:"enabled"`
+	// In milliseconds
+	HeartbeatInterval int `mapstructure:"heartbeat_interval" json:"heartbeat_interval"`
+	MaxChannels       int `mapstructure:"max_channels" json:"max_channels"`
+}
+
+type EmoteDedupCfg struct {
+	// off, warn or reject
+	Mode        string `mapstructure:"mode" json:"mode"`
+	MaxDistance int    `mapstructure:"max_distance" json:"max_distance"`
+}
+
+type EmoteProcessingCfg struct {
+	Workers int `mapstructure:"workers" json:"workers"`
+}
+
+type BansCfg struct {
+	SyncInterval time.Duration `mapstructure:"sync_interval" json:"sync_interval"`
+}
+
+type LimitsCfg struct {
+	Meta struct {
+		ChannelEmoteSlots int32 `mapstructure:"channel_emote_slots" json:"channel_emote_slots"`
+		EmoteSets         int32 `mapstructure:"emote_sets" json:"emote_sets"`
+		AccessTokens      int64 `mapstructure:"access_tokens" json:"access_tokens"`
+		BulkChannelEmotes int   `mapstructure:"bulk_channel_emotes" json:"bulk_channel_emotes"`
+	} `mapstructure:"meta" json:"meta"`
+	// Route rate limits by tag, as [limit, duration in milliseconds]
+	Route   map[string][]int `mapstructure:"route" json:"route"`
+	GqlCost struct {
+		Anonymous     int64            `mapstructure:"anonymous" json:"anonymous"`
+		Authenticated int64            `mapstructure:"authenticated" json:"authenticated"`
+		Roles         map[string]int64 `mapstructure:"roles" json:"roles"`
+	} `mapstructure:"gql_cost" json:"gql_cost"`
+	UploadQuota UploadQuotaCfg `mapstructure:"upload_quota" json:"upload_quota"`
+}
+
+type UploadQuotaCfg struct {
+	Daily         int32                          `mapstructure:"daily" json:"daily"`
+	Owned         int32                          `mapstructure:"owned" json:"owned"`
+	Roles         map[string]UploadQuotaOverride `mapstructure:"roles" json:"roles"`
+	Subscriptions map[string]UploadQuotaOverride `mapstructure:"subscriptions" json:"subscriptions"`
+}
+
+// UploadQuotaOverride raises the upload quota of some users, -1 meaning no limit. Limits left out are not raised
+type UploadQuotaOverride struct {
+	Daily *int32 `mapstructure:"daily" json:"daily"`
+	Owned *int32 `mapstructure:"owned" json:"owned"`
+}
+
+type RateLimitsCfg struct {
+	Policies map[string]RateLimitPolicy `mapstructure:"policies" json:"policies"`
+	Local    struct {
+		Share         float64       `mapstructure:"share" json:"share"`
+		ProbeInterval time.Duration `mapstructure:"probe_interval" json:"probe_interval"`
+	} `mapstructure:"local" json:"local"`
+}
+
+// RateLimitWindow is a limit on requests within a period
+type RateLimitWindow struct {
+	Limit  int32         `mapstructure:"limit" json:"limit"`
+	Period time.Duration `mapstructure:"period" json:"period"`
+}
+
+// RateLimitTier is the set of windows a caller is held to.
+// The burst window allows short spikes, while the sustained window caps steady use over a longer period
+type RateLimitTier struct {
+	Burst     *RateLimitWindow `mapstructure:"burst" json:"burst"`
+	Sustained *RateLimitWindow `mapstructure:"sustained" json:"sustained"`
+}
+
+// RateLimitPolicy is a named rate limit, resolving the tier of a caller from their access token or role
+type RateLimitPolicy struct {
+	// The tier of anonymous callers, and of any caller not matched by another tier
+	Default RateLimitTier `mapstructure:"default" json:"default"`
+	// Tiers by role ID. Roles granted by entitlements count
+	Roles map[string]RateLimitTier `mapstructure:"roles" json:"roles"`
+	// Tiers by access token ID, such as for partner bots
+	Tokens map[string]RateLimitTier `mapstructure:"tokens" json:"tokens"`
+}
+
+type GqlCfg struct {
+	PersistedQueries struct {
+		TTL      time.Duration `mapstructure:"ttl" json:"ttl"`
+		Manifest string        `mapstructure:"manifest" json:"manifest"`
+		Strict   bool          `mapstructure:"strict" json:"strict"`
+		MaxSize  int           `mapstructure:"max_size" json:"max_size"`
+	} `mapstructure:"persisted_queries" json:"persisted_queries"`
+	// Operation names which are labelled as themselves in the metrics, any other is labelled "other".
+	// The operations of the persisted query manifest are included
+	MetricsOperations []string `mapstructure:"metrics_operations" json:"metrics_operations"`
+	MaxParallelism    int      `mapstructure:"max_parallelism" json:"max_parallelism"`
+}
+
+type DataloaderCfg struct {
+	Wait     time.Duration `mapstructure:"wait" json:"wait"`
+	MaxBatch int           `mapstructure:"max_batch" json:"max_batch"`
+}
+
+type HealthCfg struct {
+	Interval time.Duration `mapstructure:"interval" json:"interval"`
+	Timeout  time.Duration `mapstructure:"timeout" json:"timeout"`
+}
+
+type ShutdownCfg struct {
+	DrainDelay time.Duration `mapstructure:"drain_delay" json:"drain_delay"`
+	Timeout    time.Duration `mapstructure:"timeout" json:"timeout"`
+}
+
+type StorageCfg struct {
+	// s3 or local
+	Backend string `mapstructure:"backend" json:"backend"`
+	Local   struct {
+		Path  string `mapstructure:"path" json:"path"`
+		Serve bool   `mapstructure:"serve" json:"serve"`
+	} `mapstructure:"local" json:"local"`
+}
+
+type DiscordCfg struct {
+	BotToken string `mapstructure:"bot_token" json:"bot_token"`
+	Webhooks struct {
+		// As [id, token]
+		Activity []string `mapstructure:"activity" json:"activity"`
+		Alerts   []string `mapstructure:"alerts" json:"alerts"`
+		// The role pinged when a service goes down
+		SysadminRole string `mapstructure:"sysadmin_role" json:"sysadmin_role"`
+	} `mapstructure:"webhooks" json:"webhooks"`
+}
+
+type ChatterinoCfg struct {
+	Version string `mapstructure:"version" json:"version"`
+	// Releases by platform
+	Stable map[string]ChatterinoRelease `mapstructure:"stable" json:"stable"`
+	Beta   map[string]ChatterinoRelease `mapstructure:"beta" json:"beta"`
+}
+
+type ChatterinoRelease struct {
+	Download         string `mapstructure:"download" json:"download"`
+	PortableDownload string `mapstructure:"portable_download" json:"portable_download"`
+	UpdateExe        string `mapstructure:"updateexe" json:"updateexe"`
+}
+
+type GoogleCfg struct {
+	APIKey string `mapstructure:"api_key" json:"api_key"`
+}
+
+type Platform struct {
+	ID         string             `mapstructure:"id" json:"id"`
+	VersionTag string             `mapstructure:"version_tag" json:"version_tag"`
+	New        bool               `mapstructure:"new" json:"new"`
+	URL        string             `mapstructure:"url" json:"url"`
+	Variants   *[]PlatformVariant `mapstructure:"variants" json:"variants"`
+}
+
+type PlatformVariant struct {
+	Name        string `json:"name" mapstructure:"name"`
+	ID          string `json:"id" mapstructure:"id"`
+	Author      string `json:"author" mapstructure:"author"`
+	Version     string `json:"version" mapstructure:"version"`
+	Description string `json:"description" mapstructure:"description"`
+	URL         string `json:"url" mapstructure:"url"`
+}
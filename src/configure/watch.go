@@ -0,0 +1,172 @@
+package configure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Subscription to the keys of the config a subsystem cares about, for applying changes to them at runtime
+type Subscription struct {
+	// The keys subscribed to, including the keys nested under them
+	Keys []string
+	// Check a reloaded config before it is applied, if any of the keys changed, beyond what Check covers.
+	// The config is only applied if it passes Check and every subscription whose keys changed accepts it
+	Validate func(c *viper.Viper) error
+	// Apply the changes once the reloaded config is in use, if any of the keys changed
+	OnChange func()
+}
+
+var (
+	subsMtx = sync.Mutex{}
+	subs    = []*Subscription{}
+)
+
+// Keys which are only read once the server starts, such as connections and the storage backend.
+// Changes to them are logged and left out of a reload, the values in use are kept until a restart
+var restartKeys = []string{
+	"conn_uri", "conn_type", "internal_conn_uri",
+	"redis_uri", "redis_db", "mongo_uri", "mongo_db", "mongo_direct",
+	"storage",
+	"websocket.enabled",
+	"emote_processing.workers", "bans.sync_interval", "health.interval", "health.timeout",
+	"gql.max_parallelism", "gql.persisted_queries.manifest",
+	"discord.bot_token",
+}
+
+// Subscribe to changes of config keys, made to the config file while the server is running
+func Subscribe(s Subscription) {
+	subsMtx.Lock()
+	defer subsMtx.Unlock()
+
+	subs = append(subs, &s)
+}
+
+// Reload the config file, applying it only if it passes Check and every subscription whose keys changed accepts it
+func Reload() error {
+	subsMtx.Lock()
+	defer subsMtx.Unlock()
+
+	b, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	candidate, err := newConfig(configFile, b)
+	if err != nil {
+		return err
+	}
+	current := Config.Viper()
+	for _, key := range restartKeys {
+		if !reflect.DeepEqual(current.Get(key), candidate.Get(key)) {
+			log.WithField("key", key).Warn("config, change takes a restart, keeping the value in use")
+			candidate.Set(key, current.Get(key))
+		}
+	}
+	if problems, _ := Check(candidate); len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+
+	changedKeys := []string{}
+	changed := []*Subscription{}
+	for _, s := range subs {
+		keyChanged := false
+		for _, key := range s.Keys {
+			if !reflect.DeepEqual(current.Get(key), candidate.Get(key)) {
+				changedKeys = append(changedKeys, key)
+				keyChanged = true
+			}
+		}
+		if keyChanged {
+			changed = append(changed, s)
+		}
+	}
+
+	errs := []string{}
+	for _, s := range changed {
+		if s.Validate == nil {
+			continue
+		}
+		if err := s.Validate(candidate); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(errs, "; "))
+	}
+
+	// Swap in the new config as a whole, leaving the one being read by requests as it was
+	Config.set(candidate)
+
+	if len(changed) > 0 {
+		log.WithField("keys", changedKeys).Info("config, reloaded")
+	}
+	for _, s := range changed {
+		if s.OnChange != nil {
+			s.OnChange()
+		}
+	}
+
+	return nil
+}
+
+// Reload the config file whenever it is written to, or replaced as Kubernetes does with mounted config maps
+func watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.WithError(err).Error("config, could not watch the config file")
+		return
+	}
+
+	file := filepath.Clean(configFile)
+	realFile, _ := filepath.EvalSymlinks(file)
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		log.WithError(err).Error("config, could not watch the config file")
+		_ = watcher.Close()
+		return
+	}
+
+	go func() {
+		// Editors write a file in several steps, so reload once they are done
+		var debounce *time.Timer
+		reload := func() {
+			if err := Reload(); err != nil {
+				log.WithError(err).Error("config, reload rejected")
+			}
+		}
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				currentFile, _ := filepath.EvalSymlinks(file)
+				written := filepath.Clean(event.Name) == file && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+				swapped := currentFile != "" && currentFile != realFile
+				if !written && !swapped {
+					continue
+				}
+				realFile = currentFile
+
+				if debounce != nil {
+					debounce.Stop()
+				}
+				debounce = time.AfterFunc(time.Millisecond*100, reload)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.WithError(err).Error("config, watcher")
+			}
+		}
+	}()
+}
@@ -1,8 +1,10 @@
 package discord
 
 import (
+	"context"
 	"fmt"
 	"strconv"
+	"sync"
 
 	"github.com/SevenTV/ServerGo/src/configure"
 	dgo "github.com/bwmarrin/discordgo"
@@ -11,29 +13,49 @@ import (
 
 // An empty Discord session for executing webhooks
 var d, _ = dgo.New(fmt.Sprintf("Bot %v", configure.Config.GetString("discord.bot_token")))
-var webhooks = make(map[string]webhookInfo)
+var (
+	webhooksMtx = sync.RWMutex{}
+	webhooks    = make(map[string]webhookInfo)
+)
 
 type webhookInfo struct {
 	ID    string
 	Token string
 }
 
+// The webhooks which can be sent to, as set in config
+var webhookNames = []string{"activity", "alerts"}
+
 func init() {
-	s := configure.Config.GetStringSlice("discord.webhooks.activity")
-	if len(s) == 2 {
-		webhooks["activity"] = webhookInfo{
-			ID:    s[0],
-			Token: s[1],
-		}
-	}
+	webhooks = readWebhooks()
 
-	s = configure.Config.GetStringSlice("discord.webhooks.alerts")
-	if len(s) == 2 {
-		webhooks["alerts"] = webhookInfo{
-			ID:    s[0],
-			Token: s[1],
+	// Webhooks can be added, changed or removed without a restart
+	configure.Subscribe(configure.Subscription{
+		Keys: []string{"discord.webhooks"},
+		OnChange: func() {
+			wh := readWebhooks()
+
+			webhooksMtx.Lock()
+			webhooks = wh
+			webhooksMtx.Unlock()
+		},
+	})
+}
+
+// Read the webhooks of the config, each given as [id, token]
+func readWebhooks() map[string]webhookInfo {
+	result := make(map[string]webhookInfo)
+	for _, name := range webhookNames {
+		s := configure.Config.GetStringSlice("discord.webhooks." + name)
+		if len(s) == 2 {
+			result[name] = webhookInfo{
+				ID:    s[0],
+				Token: s[1],
+			}
 		}
 	}
+
+	return result
 }
 
 func toIntColor(s string) int {
@@ -45,8 +67,22 @@ func toIntColor(s string) int {
 	return int(i)
 }
 
+// Webhooks being sent, waited for on shutdown
+var pending = sync.WaitGroup{}
+
+// Go sends webhooks in the background. They are counted before the goroutine starts, so that Wait doesn't miss them
+func Go(send func()) {
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		send()
+	}()
+}
+
 func SendWebhook(name string, params *dgo.WebhookParams) *dgo.Message {
+	webhooksMtx.RLock()
 	wh, ok := webhooks[name]
+	webhooksMtx.RUnlock()
 	if !ok || (wh.ID == "" || wh.Token == "") {
 		// Discord is disabled.
 		return nil
@@ -61,4 +97,20 @@ func SendWebhook(name string, params *dgo.WebhookParams) *dgo.Message {
 	return nil
 }
 
+// Wait for the webhooks being sent, until the context is done
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 var Discord = d
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/expfmt"
+	log "github.com/sirupsen/logrus"
+)
+
+const namespace = "seventv"
+
+var (
+	// HTTP requests served, by route, method and status
+	HttpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Amount of HTTP requests served",
+	}, []string{"route", "method", "status"})
+
+	// Time taken to serve HTTP requests, by route, method and status
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// Time taken to execute GraphQL operations, by operation name and whether it returned errors
+	GqlOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "gql",
+		Name:      "operation_duration_seconds",
+		Help:      "Time taken to execute GraphQL operations",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "result"})
+
+	// Lookups through the redis cache, by collection and whether they were served from the cache
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Amount of lookups through the redis cache, by result (hit, partial or miss)",
+	}, []string{"collection", "result"})
+
+	// Requests rejected for exceeding a rate limit, by rate limit tag
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ratelimit",
+		Name:      "rejections_total",
+		Help:      "Amount of requests rejected for exceeding a rate limit",
+	}, []string{"tag"})
+
+	// Time taken to upload files to storage, by whether the upload succeeded
+	StorageUploadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "upload_duration_seconds",
+		Help:      "Time taken to upload files to storage",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"result"})
+
+	// Runs of background tasks, by task and whether the run succeeded
+	TaskRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "runs_total",
+		Help:      "Amount of background task runs",
+	}, []string{"task", "result"})
+
+	// Time taken by runs of background tasks
+	TaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "duration_seconds",
+		Help:      "Time taken by background task runs",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"task"})
+
+	// When a background task last ran successfully
+	TaskLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "task",
+		Name:      "last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful background task run",
+	}, []string{"task"})
+)
+
+// Get the result label of an operation which may have failed
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "success"
+}
+
+// Record a run of a background task which started at the given time
+func ObserveTask(task string, start time.Time, err error) {
+	TaskRuns.WithLabelValues(task, Result(err)).Inc()
+	TaskDuration.WithLabelValues(task).Observe(time.Since(start).Seconds())
+	if err == nil {
+		TaskLastSuccess.WithLabelValues(task).SetToCurrentTime()
+	}
+}
+
+// Serve the metrics in the Prometheus text format
+func Metrics(app fiber.Router) {
+	app.Get("/metrics", func(c *fiber.Ctx) error {
+		families, err := prometheus.DefaultGatherer.Gather()
+		if err != nil {
+			log.WithError(err).Error("metrics")
+			return c.SendStatus(500)
+		}
+
+		buf := bytes.Buffer{}
+		enc := expfmt.NewEncoder(&buf, expfmt.FmtText)
+		for _, mf := range families {
+			if err := enc.Encode(mf); err != nil {
+				log.WithError(err).Error("metrics")
+				return c.SendStatus(500)
+			}
+		}
+
+		c.Set("Content-Type", string(expfmt.FmtText))
+		return c.Send(buf.Bytes())
+	})
+}
@@ -1,3 +1,16 @@
 package cache
 
-var CachedRoles interface{}
+import "sync/atomic"
+
+// The roles held in memory, replaced as a whole whenever they are refreshed
+var cachedRoles atomic.Value
+
+// GetRoles returns the roles held in memory, or nil if they were never fetched
+func GetRoles() interface{} {
+	return cachedRoles.Load()
+}
+
+// SetRoles replaces the roles held in memory. The slice must not be modified afterwards, as it is shared by readers
+func SetRoles(roles interface{}) {
+	cachedRoles.Store(roles)
+}
@@ -0,0 +1,58 @@
+package datastructure
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The prefix of personal access tokens, telling them apart from session tokens
+const AccessTokenPrefix = "7tv_pat_"
+
+// AccessToken is a long-lived personal access token, used by bots and integrations to act as a user
+// Only the hash of the token is stored
+type AccessToken struct {
+	ID primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	// The user the token acts as
+	UserID primitive.ObjectID `json:"user_id" bson:"user_id"`
+	// A name to recognize the token by
+	Name string `json:"name" bson:"name"`
+	// What the token is allowed to do
+	Scopes []AccessTokenScope `json:"scopes" bson:"scopes"`
+	// SHA-256 hash of the token
+	TokenHash  string     `json:"-" bson:"token_hash"`
+	CreatedAt  time.Time  `json:"created_at" bson:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" bson:"last_used_at"`
+}
+
+// Test whether an access token was granted a scope
+func (t *AccessToken) HasScope(scope AccessTokenScope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A string representing what an access token is allowed to do
+type AccessTokenScope string
+
+var (
+	AccessTokenScopeEmotesRead         = AccessTokenScope("emotes:read")          // Query emotes
+	AccessTokenScopeEmotesWrite        = AccessTokenScope("emotes:write")         // Upload, edit, delete and restore emotes
+	AccessTokenScopeChannelEmotesWrite = AccessTokenScope("channel-emotes:write") // Add, edit and remove channel emotes and manage emote sets
+	AccessTokenScopeEditorsWrite       = AccessTokenScope("editors:write")        // Add and remove channel editors
+	AccessTokenScopeReportsWrite       = AccessTokenScope("reports:write")        // Report emotes and users
+	AccessTokenScopeUsersWrite         = AccessTokenScope("users:write")          // Edit the user and mark notifications read
+
+	AccessTokenScopes = []AccessTokenScope{
+		AccessTokenScopeEmotesRead,
+		AccessTokenScopeEmotesWrite,
+		AccessTokenScopeChannelEmotesWrite,
+		AccessTokenScopeEditorsWrite,
+		AccessTokenScopeReportsWrite,
+		AccessTokenScopeUsersWrite,
+	}
+)
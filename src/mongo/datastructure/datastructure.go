@@ -18,12 +18,16 @@ type Emote struct {
 	Visibility       int32                `json:"visibility" bson:"visibility"`
 	Mime             string               `json:"mime" bson:"mime"`
 	Status           int32                `json:"status" bson:"status"`
+	StatusMessage    string               `json:"status_message,omitempty" bson:"status_message,omitempty"` // The reason processing failed, if it did
 	Tags             []string             `json:"tags" bson:"tags"`
 	SharedWith       []primitive.ObjectID `json:"shared_with" bson:"shared_with"`
 	LastModifiedDate time.Time            `json:"edited_at" bson:"edited_at"`
 	Width            [4]int16             `json:"width" bson:"width"`   // The emote's width in pixels
 	Height           [4]int16             `json:"height" bson:"height"` // The emote's height in pixels
 	Animated         bool                 `json:"animated" bson:"animated"`
+	PHash            []int64              `json:"-" bson:"phash,omitempty"`       // Perceptual hashes of the emote's frames
+	PHashBands       []int32              `json:"-" bson:"phash_bands,omitempty"` // Segments of the frames' perceptual hashes, used to look up similar emotes
+	Similar          []primitive.ObjectID `json:"-" bson:"similar,omitempty"`     // Live emotes which looked like the upload when it was processed
 
 	// ChannelCount is used during the popularity sort check, generated by a pipeline.
 	// It is not used anywhere else
@@ -97,8 +101,21 @@ const (
 	EmoteStatusPending
 	EmoteStatusDisabled
 	EmoteStatusLive
+	EmoteStatusFailed // Processing the uploaded file failed, see the emote's status message
 )
 
+// An EmoteSet is a named list of emotes owned by a channel, which can be switched to as the channel's active emotes
+type EmoteSet struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	OwnerID   primitive.ObjectID   `json:"owner_id" bson:"owner"`
+	Name      string               `json:"name" bson:"name"`
+	EmoteIDs  []primitive.ObjectID `json:"emote_ids" bson:"emotes"`
+	CreatedAt time.Time            `json:"created_at" bson:"created_at"`
+
+	// Relational Data
+	Emotes *[]*Emote `json:"emotes" bson:"-"`
+}
+
 type User struct {
 	ID           primitive.ObjectID   `json:"_id" bson:"_id,omitempty"`
 	Email        string               `json:"email" bson:"email"`
@@ -106,6 +123,7 @@ type User struct {
 	EmoteIDs     []primitive.ObjectID `json:"emote_ids" bson:"emotes"`
 	EditorIDs    []primitive.ObjectID `json:"editor_ids" bson:"editors"`
 	RoleID       *primitive.ObjectID  `json:"role_id" bson:"role"`
+	RolePosition *int32               `json:"-" bson:"role_position,omitempty"` // The position of the role, kept alongside it to sort users by
 	TokenVersion string               `json:"token_version" bson:"token_version"`
 
 	// Twitch Data
@@ -120,9 +138,10 @@ type User struct {
 	CreatedAt        time.Time           `json:"twitch_created_at" bson:"twitch_created_at"`
 	ViewCount        int32               `json:"view_count" bson:"view_count"`
 	ProfilePictureID string              `json:"profile_picture_id,omitempty" bson:"profile_picture_id,omitempty"`
-	EmoteAlias       map[string]string   `json:"-" bson:"emote_alias"`           // Emote Alias - backend only
-	Badge            *primitive.ObjectID `json:"badge" bson:"badge"`             // User's badge, if any
-	EmoteSlots       int32               `json:"emote_slots" bson:"emote_slots"` // User's maximum channel emote slots
+	EmoteAlias       map[string]string   `json:"-" bson:"emote_alias"`                                  // Emote Alias - backend only
+	Badge            *primitive.ObjectID `json:"badge" bson:"badge"`                                    // User's badge, if any
+	EmoteSlots       int32               `json:"emote_slots" bson:"emote_slots"`                        // User's maximum channel emote slots
+	ActiveEmoteSetID *primitive.ObjectID `json:"active_emote_set_id" bson:"active_emote_set,omitempty"` // The emote set in use, mirrored to EmoteIDs
 
 	// Relational Data
 	Emotes            *[]*Emote       `json:"emotes" bson:"-"`
@@ -137,6 +156,15 @@ type User struct {
 	NotificationCount *int64          `json:"-" bson:"-"`
 }
 
+// Get the maximum amount of emote sets a user can own
+func (u *User) GetEmoteSetLimit() int32 {
+	if limit := configure.Config.GetInt32("limits.meta.emote_sets"); limit > 0 {
+		return limit
+	}
+
+	return 10
+}
+
 // Get the user's maximum emote slot count
 func (u *User) GetEmoteSlots() int32 {
 	if u.EmoteSlots == 0 {
@@ -188,7 +216,7 @@ func GetRole(id *primitive.ObjectID) Role {
 	}
 
 	rid := *id
-	roles := cache.CachedRoles.([]Role)
+	roles, _ := cache.GetRoles().([]Role)
 
 	for _, r := range roles {
 		if r.ID != rid {
@@ -256,16 +284,42 @@ type AuditLogChange struct {
 type Report struct {
 	ID         primitive.ObjectID  `json:"id" bson:"_id"`
 	ReporterID *primitive.ObjectID `json:"reporter_id" bson:"reporter_id"`
-	Reason     string              `json:"reason" bson:"target"`
+	Reason     string              `json:"reason" bson:"reason"`
 	Target     *Target             `json:"target" bson:"target"`
 	Cleared    bool                `json:"cleared" bson:"cleared"`
+	AssigneeID *primitive.ObjectID `json:"assignee_id" bson:"assignee_id,omitempty"` // The moderator handling the report
+	Notes      []*ReportNote       `json:"notes" bson:"notes,omitempty"`             // Internal notes by moderators
+	Resolution *ReportResolution   `json:"resolution" bson:"resolution,omitempty"`   // How the report was resolved, once cleared
 
 	ETarget      *Emote       `json:"e_target" bson:"-"`
 	UTarget      *User        `json:"u_target" bson:"-"`
 	Reporter     *User        `json:"reporter" bson:"-"`
+	Assignee     *User        `json:"assignee" bson:"-"`
 	AuditEntries *[]*AuditLog `json:"audit_entries" bson:"-"`
 }
 
+type ReportNote struct {
+	AuthorID  primitive.ObjectID `json:"author_id" bson:"author_id"`
+	Content   string             `json:"content" bson:"content"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type ReportResolution struct {
+	Outcome    ReportOutcome       `json:"outcome" bson:"outcome"`
+	ResolvedBy primitive.ObjectID  `json:"resolved_by" bson:"resolved_by"`
+	ResolvedAt time.Time           `json:"resolved_at" bson:"resolved_at"`
+	AuditLogID *primitive.ObjectID `json:"audit_log_id" bson:"audit_log_id,omitempty"` // The audit log of the action taken on the target
+}
+
+type ReportOutcome string
+
+const (
+	ReportOutcomeDismissed    ReportOutcome = "DISMISSED"     // No action was needed
+	ReportOutcomeEmoteDeleted ReportOutcome = "EMOTE_DELETED" // The reported emote was deleted
+	ReportOutcomeEmoteEdited  ReportOutcome = "EMOTE_EDITED"  // The reported emote was edited
+	ReportOutcomeUserBanned   ReportOutcome = "USER_BANNED"   // The reported user was banned
+)
+
 const (
 	// Emotes (1-19)
 	AuditLogTypeEmoteCreate     = 1
@@ -290,6 +344,11 @@ const (
 	AuditLogTypeUserChannelEditorAdd    = 37
 	AuditLogTypeUserChannelEditorRemove = 38
 	AuditLogTypeUserChannelEmoteEdit    = 39
+	AuditLogTypeUserEmoteSetCreate      = 40
+	AuditLogTypeUserEmoteSetEdit        = 41
+	AuditLogTypeUserEmoteSetActivate    = 42
+	AuditLogTypeUserAccessTokenCreate   = 43
+	AuditLogTypeUserAccessTokenRevoke   = 44
 
 	// Admin (70-89)
 	AuditLogTypeAppMaintenanceMode = 70
@@ -304,6 +363,12 @@ const (
 	// Reports (90-99)
 	AuditLogTypeReport      = 90
 	AuditLogTypeReportClear = 91
+	AuditLogTypeReportEdit  = 92
+
+	// Roles (100-109)
+	AuditLogTypeRoleCreate = 100
+	AuditLogTypeRoleEdit   = 101
+	AuditLogTypeRoleDelete = 102
 )
 
 type Badge struct {
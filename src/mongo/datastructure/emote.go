@@ -3,6 +3,7 @@ package datastructure
 import (
 	"fmt"
 	"io"
+	"math/bits"
 	"net/http"
 
 	"gopkg.in/gographics/imagick.v3/imagick"
@@ -70,6 +71,133 @@ func (*emoteUtil) GetFilesMeta(fileDir string) [][]string {
 	}
 }
 
+// The amount of frames sampled across animated emotes, in addition to the first frame, when computing perceptual hashes
+const PHashSampleFrames = 8
+
+// The amount of segments a perceptual hash is split into for lookups.
+// Two hashes within a distance of PHashBandCount-1 always share at least one segment
+const PHashBandCount = 4
+
+//
+// Compute perceptual hashes of an emote
+// The first hash is of the first frame, animated emotes also get hashes of frames sampled evenly across the animation
+//
+func (*emoteUtil) ComputePHash(path string) ([]int64, error) {
+	wand := imagick.NewMagickWand()
+	defer wand.Destroy()
+	if err := wand.SetResourceLimit(imagick.RESOURCE_MEMORY, 500); err != nil {
+		log.WithError(err).Error("SetResourceLimit")
+	}
+	if err := wand.ReadImage(path); err != nil {
+		return nil, err
+	}
+
+	// Merge all frames with coalesce, so that each frame is complete
+	coalesce := wand.CoalesceImages()
+	defer coalesce.Destroy()
+
+	count := int(coalesce.GetNumberImages())
+	indexes := []int{0}
+	if count > 1 {
+		for i := 1; i <= PHashSampleFrames; i++ {
+			indexes = append(indexes, i*count/(PHashSampleFrames+1))
+		}
+	}
+
+	hashes := make([]int64, len(indexes))
+	for i, ind := range indexes {
+		coalesce.SetIteratorIndex(ind)
+		frame := coalesce.GetImage()
+		hash, err := dHash(frame)
+		frame.Destroy()
+		if err != nil {
+			return nil, err
+		}
+
+		hashes[i] = hash
+	}
+
+	return hashes, nil
+}
+
+// Compute the difference hash of a frame: whether the brightness increases between horizontally adjacent pixels of a 9x8 thumbnail
+func dHash(frame *imagick.MagickWand) (int64, error) {
+	if err := frame.ResizeImage(9, 8, imagick.FILTER_BOX); err != nil {
+		return 0, err
+	}
+
+	v, err := frame.ExportImagePixels(0, 0, 9, 8, "RGBA", imagick.PIXEL_CHAR)
+	if err != nil {
+		return 0, err
+	}
+	pixels, ok := v.([]byte)
+	if !ok || len(pixels) != 9*8*4 {
+		return 0, fmt.Errorf("unexpected pixel data")
+	}
+
+	// Get the brightness of each pixel, with transparency as white
+	gray := make([]int, 9*8)
+	for i := range gray {
+		r, g, b, a := int(pixels[i*4]), int(pixels[i*4+1]), int(pixels[i*4+2]), int(pixels[i*4+3])
+		l := (r*299 + g*587 + b*114) / 1000
+		gray[i] = (l*a + 255*(255-a)) / 255
+	}
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if gray[y*9+x] < gray[y*9+x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return int64(hash), nil
+}
+
+//
+// Split the perceptual hash of each frame into indexed segments, tagged with their position
+// Emotes share a segment if any of their frames do, so that animations are found even if their first frames differ
+//
+func (*emoteUtil) PHashBands(hashes []int64) []int32 {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	bands := []int32{}
+	seen := map[int32]bool{}
+	for _, hash := range hashes {
+		for i := 0; i < PHashBandCount; i++ {
+			segment := (uint64(hash) >> (16 * i)) & 0xffff
+			band := int32(i<<16) | int32(segment)
+			if !seen[band] {
+				seen[band] = true
+				bands = append(bands, band)
+			}
+		}
+	}
+
+	return bands
+}
+
+//
+// Get the distance between the perceptual hashes of two emotes, as the average amount of differing bits per frame
+// Returns -1 if the emotes can't be compared, i.e if one is animated and the other isn't
+//
+func (*emoteUtil) PHashDistance(a, b []int64) int {
+	if len(a) == 0 || len(a) != len(b) {
+		return -1
+	}
+
+	sum := 0
+	for i := range a {
+		sum += bits.OnesCount64(uint64(a[i] ^ b[i]))
+	}
+
+	return sum / len(a)
+}
+
 var EmoteUtil emoteUtil
 
 func init() {
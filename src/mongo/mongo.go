@@ -61,6 +61,16 @@ func init() {
 			"status": datastructure.EmoteStatusDeleted,
 		})},
 		{Keys: bson.M{"channel_count_checked_at": 1}},
+		{Keys: bson.M{"phash_bands": 1}},
+		{Keys: bson.D{{Key: "channel_count", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		log.WithError(err).Fatal("mongo")
+	}
+
+	_, err = Collection(CollectionNameEmoteSets).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"owner": 1}},
+		{Keys: bson.M{"emotes": 1}},
 	})
 	if err != nil {
 		log.WithError(err).Fatal("mongo")
@@ -73,6 +83,7 @@ func init() {
 		{Keys: bson.M{"role": 1}},
 		{Keys: bson.M{"editors": 1}},
 		{Keys: bson.M{"emotes": 1}},
+		{Keys: bson.D{{Key: "emotes", Value: 1}, {Key: "role_position", Value: 1}, {Key: "_id", Value: 1}}},
 	})
 	if err != nil {
 		log.WithError(err).Fatal("mongo")
@@ -100,6 +111,8 @@ func init() {
 		{Keys: bson.M{"reporter_id": 1}},
 		{Keys: bson.M{"target.type": 1}},
 		{Keys: bson.M{"target.id": 1}},
+		{Keys: bson.D{{Key: "cleared", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.M{"assignee_id": 1}},
 	})
 	if err != nil {
 		log.WithError(err).Fatal("mongo")
@@ -121,12 +134,25 @@ func init() {
 		log.WithError(err).Fatal("mongo")
 	}
 
+	_, err = Collection(CollectionNameAccessTokens).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"token_hash": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"user_id": 1}},
+	})
+	if err != nil {
+		log.WithError(err).Fatal("mongo")
+	}
+
 	_, err = Collection(CollectionNameEntitlements).Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{Keys: bson.M{"user_id": 1}},
 		{Keys: bson.M{"data.ref": 1}},
 	})
 }
 
+// Close the connection to the database, once the server is shutting down
+func Close(ctx context.Context) error {
+	return Database.Client().Disconnect(ctx)
+}
+
 func Collection(name CollectionName) *mongo.Collection {
 	return Database.Collection(string(name))
 }
@@ -135,6 +161,7 @@ type CollectionName string
 
 var (
 	CollectionNameEmotes            = CollectionName("emotes")
+	CollectionNameEmoteSets         = CollectionName("emote_sets")
 	CollectionNameUsers             = CollectionName("users")
 	CollectionNameBans              = CollectionName("bans")
 	CollectionNameReports           = CollectionName("reports")
@@ -144,6 +171,7 @@ var (
 	CollectionNameEntitlements      = CollectionName("entitlements")
 	CollectionNameNotifications     = CollectionName("notifications")
 	CollectionNameNotificationsRead = CollectionName("notifications_read")
+	CollectionNameAccessTokens      = CollectionName("access_tokens")
 )
 
 func HexIDSliceToObjectID(arr []string) []primitive.ObjectID {
@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ModeRedis = "redis" // Limits are shared by all pods through redis
+	ModeLocal = "local" // Redis is unhealthy, so each pod limits requests by itself
+)
+
+// State is which limiter is active, and since when
+type State struct {
+	Mode      string     `json:"mode"`
+	Since     *time.Time `json:"since"`
+	LastError *string    `json:"last_error"`
+}
+
+var (
+	stateMtx = sync.RWMutex{}
+	state    = State{Mode: ModeRedis}
+	probing  int32
+	probedAt int64
+
+	bucketsMtx = sync.Mutex{}
+	buckets    = map[string]*bucket{}
+	sweptAt    = time.Now()
+)
+
+// GetState returns which limiter is active
+func GetState() State {
+	stateMtx.RLock()
+	defer stateMtx.RUnlock()
+
+	return state
+}
+
+func isLocal() bool {
+	stateMtx.RLock()
+	defer stateMtx.RUnlock()
+
+	return state.Mode == ModeLocal
+}
+
+func setMode(mode string, err error) {
+	stateMtx.Lock()
+	defer stateMtx.Unlock()
+
+	if state.Mode == mode {
+		return
+	}
+	now := time.Now()
+	state.Mode = mode
+	state.Since = &now
+	if err != nil {
+		msg := err.Error()
+		state.LastError = &msg
+	}
+
+	if mode == ModeLocal {
+		log.WithError(err).Warn("ratelimit, redis is unhealthy, limiting requests locally")
+	} else {
+		log.Info("ratelimit, redis is healthy again, limiting requests through redis")
+	}
+}
+
+// Check redis in the background, at most once per interval, switching back to it once it responds
+func probeRedis() {
+	now := time.Now().UnixNano()
+	if now-atomic.LoadInt64(&probedAt) < int64(getProbeInterval()) || !atomic.CompareAndSwapInt32(&probing, 0, 1) {
+		return
+	}
+	atomic.StoreInt64(&probedAt, now)
+
+	go func() {
+		defer atomic.StoreInt32(&probing, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+		defer cancel()
+		if err := redis.Client.Ping(ctx).Err(); err != nil {
+			return
+		}
+		setMode(ModeRedis, nil)
+	}()
+}
+
+func getProbeInterval() time.Duration {
+	if d := configure.Config.GetDuration("rate_limits.local.probe_interval"); d > 0 {
+		return d
+	}
+
+	return time.Second * 5
+}
+
+// The share of each window's limit a pod allows on its own, as all pods limit requests apart from each other
+func getLocalShare() float64 {
+	if share := configure.Config.GetFloat64("rate_limits.local.share"); share > 0 && share <= 1 {
+		return share
+	}
+
+	return 0.25
+}
+
+// A token bucket, refilling its capacity over the period of a window
+type bucket struct {
+	tokens    float64
+	capacity  float64
+	rate      float64 // Tokens per second
+	updatedAt time.Time
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.updatedAt).Seconds()*b.rate)
+	b.updatedAt = now
+}
+
+// Count a request against in-memory token buckets, in place of the windows in redis
+func consumeLocal(policy string, caller Caller, windows []namedWindow) Result {
+	now := time.Now()
+	share := getLocalShare()
+
+	bucketsMtx.Lock()
+	defer bucketsMtx.Unlock()
+
+	// Forget buckets which have refilled completely
+	if now.Sub(sweptAt) > time.Minute {
+		for key, b := range buckets {
+			if b.refill(now); b.tokens >= b.capacity {
+				delete(buckets, key)
+			}
+		}
+		sweptAt = now
+	}
+
+	result := Result{Allowed: true}
+	used := make([]*bucket, len(windows))
+	for i, w := range windows {
+		key := windowKey(policy, caller, w.name)
+		b, ok := buckets[key]
+		if !ok {
+			capacity := math.Max(1, math.Floor(float64(w.Limit)*share))
+			b = &bucket{
+				tokens:    capacity,
+				capacity:  capacity,
+				rate:      capacity / w.Period.Seconds(),
+				updatedAt: now,
+			}
+			buckets[key] = b
+		}
+		b.refill(now)
+		used[i] = b
+
+		if b.tokens < 1 {
+			result.Allowed = false
+		}
+	}
+
+	for i, b := range used {
+		if result.Allowed {
+			b.tokens--
+		}
+
+		remaining := int32(b.tokens)
+		if i == 0 || remaining < result.Remaining {
+			result.Limit = int32(b.capacity)
+			result.Remaining = remaining
+			// Seconds until a token is available again
+			result.Reset = int64(math.Ceil(math.Max(0, 1-b.tokens) / b.rate))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"sync"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	log "github.com/sirupsen/logrus"
+)
+
+// Window is a limit on requests within a period
+type Window = configure.RateLimitWindow
+
+// Tier is the set of windows a caller is held to
+type Tier = configure.RateLimitTier
+
+// Policy is a named rate limit, resolving the tier of a caller from their access token or role
+type Policy = configure.RateLimitPolicy
+
+var (
+	policiesMtx sync.RWMutex
+	policies    map[string]Policy
+	// The limits routes were registered with, for the policies which aren't configured
+	fallbacks = map[string]Window{}
+)
+
+// Register a policy with the burst window used when it isn't configured, as set in code by the route using it
+func Register(name string, fallback Window) {
+	policiesMtx.Lock()
+	defer policiesMtx.Unlock()
+
+	fallbacks[name] = fallback
+}
+
+// Names returns the names of all policies, whether configured or registered by a route
+func Names() []string {
+	all := getPolicies()
+
+	policiesMtx.RLock()
+	defer policiesMtx.RUnlock()
+
+	names := []string{}
+	for name := range fallbacks {
+		names = append(names, name)
+	}
+	for name := range all {
+		if _, ok := fallbacks[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func init() {
+	// Policies are decoded again on their next use once they are changed
+	configure.Subscribe(configure.Subscription{
+		Keys: []string{"rate_limits.policies"},
+		OnChange: func() {
+			policiesMtx.Lock()
+			policies = nil
+			policiesMtx.Unlock()
+		},
+	})
+}
+
+func getPolicies() map[string]Policy {
+	policiesMtx.RLock()
+	p := policies
+	policiesMtx.RUnlock()
+	if p != nil {
+		return p
+	}
+
+	p = map[string]Policy{}
+	if err := configure.Config.UnmarshalKey("rate_limits.policies", &p); err != nil {
+		log.WithError(err).Error("ratelimit, invalid policies")
+	}
+
+	policiesMtx.Lock()
+	policies = p
+	policiesMtx.Unlock()
+	return p
+}
+
+// Resolve the tier of a caller under a policy, naming the tier they matched.
+// Windows missing from a matched tier are taken from the default tier, and the burst window from the fallback the policy was registered with
+func Resolve(name string, caller Caller) (string, Tier) {
+	policy := getPolicies()[name]
+
+	policiesMtx.RLock()
+	fallback, hasFallback := fallbacks[name]
+	policiesMtx.RUnlock()
+
+	tierName, tier := "default", policy.Default
+	if t, ok := policy.Tokens[caller.TokenID]; ok && caller.TokenID != "" {
+		tierName, tier = "token:"+caller.TokenID, t
+	} else if t, ok := policy.Roles[caller.RoleID]; ok && caller.RoleID != "" {
+		tierName, tier = "role:"+caller.RoleID, t
+	}
+
+	if tier.Burst == nil {
+		tier.Burst = policy.Default.Burst
+	}
+	if tier.Sustained == nil {
+		tier.Sustained = policy.Default.Sustained
+	}
+	if tier.Burst == nil && hasFallback {
+		tier.Burst = &fallback
+	}
+
+	return tierName, tier
+}
@@ -0,0 +1,160 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/utils"
+)
+
+// Caller identifies who a request is counted against, and what decides their tier
+type Caller struct {
+	// The user ID or IP address requests are counted against
+	Identifier string
+	// The hex ID of the caller's role, if authenticated
+	RoleID string
+	// The hex ID of the access token used, if any. It only decides the tier, requests count against the user
+	TokenID string
+}
+
+// Result is the outcome of counting a request under a policy.
+// Limit, Remaining and Reset describe the window closest to being exhausted
+type Result struct {
+	Allowed   bool
+	Tier      string
+	Limit     int32
+	Remaining int32
+	// Seconds until the window resets
+	Reset int64
+}
+
+// Consumption is how much of a window a caller has used
+type Consumption struct {
+	Policy    string
+	Tier      string
+	Window    string
+	Limit     int32
+	Used      int32
+	Remaining int32
+	// Seconds until the window resets
+	Reset int64
+}
+
+type namedWindow struct {
+	name string
+	Window
+}
+
+func tierWindows(t Tier) []namedWindow {
+	windows := []namedWindow{}
+	if t.Burst != nil && t.Burst.Limit > 0 && t.Burst.Period > 0 {
+		windows = append(windows, namedWindow{"burst", *t.Burst})
+	}
+	if t.Sustained != nil && t.Sustained.Limit > 0 && t.Sustained.Period > 0 {
+		windows = append(windows, namedWindow{"sustained", *t.Sustained})
+	}
+
+	return windows
+}
+
+func windowKey(policy string, caller Caller, window string) string {
+	h := sha1.New()
+	h.Write(utils.S2B(caller.Identifier))
+	h.Write(utils.S2B(policy))
+	h.Write(utils.S2B(":" + window))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Consume counts a request by a caller under a policy, unless it would exceed one of the windows of their tier.
+// While redis is unhealthy, requests are counted by this pod alone, against a share of the limits
+func Consume(ctx context.Context, policy string, caller Caller) (Result, error) {
+	tierName, tier := Resolve(policy, caller)
+	result := Result{Allowed: true, Tier: tierName}
+
+	windows := tierWindows(tier)
+	if len(windows) == 0 {
+		return result, nil
+	}
+
+	if isLocal() {
+		probeRedis()
+		local := consumeLocal(policy, caller, windows)
+		local.Tier = tierName
+		return local, nil
+	}
+
+	rw := make([]redis.RateLimitWindow, len(windows))
+	for i, w := range windows {
+		rw[i] = redis.RateLimitWindow{
+			Key:    windowKey(policy, caller, w.name),
+			Period: w.Period,
+			Limit:  w.Limit,
+		}
+	}
+	allowed, counts, err := redis.RateLimit(ctx, 1, rw)
+	if err != nil {
+		// Keep limiting requests while redis is unhealthy
+		setMode(ModeLocal, err)
+		local := consumeLocal(policy, caller, windows)
+		local.Tier = tierName
+		return local, nil
+	}
+
+	result.Allowed = allowed
+	for i, w := range windows {
+		remaining := w.Limit - int32(counts[i].Count)
+		if remaining < 0 {
+			remaining = 0
+		}
+		if i == 0 || remaining < result.Remaining {
+			result.Limit = w.Limit
+			result.Remaining = remaining
+			result.Reset = counts[i].TTL
+		}
+	}
+
+	return result, nil
+}
+
+// GetConsumption returns how much of each window of every policy a caller has used
+func GetConsumption(ctx context.Context, caller Caller) ([]Consumption, error) {
+	names := Names()
+	sort.Strings(names)
+
+	result := []Consumption{}
+	keys := []string{}
+	for _, name := range names {
+		tierName, tier := Resolve(name, caller)
+		for _, w := range tierWindows(tier) {
+			result = append(result, Consumption{
+				Policy: name,
+				Tier:   tierName,
+				Window: w.name,
+				Limit:  w.Limit,
+			})
+			keys = append(keys, windowKey(name, caller, w.name))
+		}
+	}
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	counts, err := redis.GetRateLimitCounts(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		result[i].Used = int32(counts[i].Count)
+		result[i].Remaining = result[i].Limit - result[i].Used
+		if result[i].Remaining < 0 {
+			result[i].Remaining = 0
+		}
+		result[i].Reset = counts[i].TTL
+	}
+
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	log "github.com/sirupsen/logrus"
+)
+
+// The amount of events kept per channel for clients resuming after a reconnect
+const channelEmotesHistoryLength = 100
+
+// How long a channel's event history is kept after its last event
+const channelEmotesHistoryTTL = time.Hour
+
+// PublishChannelEmotesEvent stores a channel emote event in the channel's history and publishes it
+func PublishChannelEmotesEvent(ctx context.Context, channel string, event EventApiV1ChannelEmotes) error {
+	historyKey := fmt.Sprintf("events-v1:channel-emotes:%s:history", channel)
+
+	j, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// Add the event to the history stream, the ID assigned by redis becomes the event ID
+	id, err := Client.XAdd(ctx, &redis.XAddArgs{
+		Stream: historyKey,
+		MaxLen: channelEmotesHistoryLength,
+		Approx: true,
+		Values: map[string]interface{}{"data": j},
+	}).Result()
+	if err != nil {
+		log.WithError(err).WithField("channel", channel).Error("redis, could not store channel emotes event")
+	} else {
+		event.EventID = id
+		Client.Expire(ctx, historyKey, channelEmotesHistoryTTL)
+	}
+
+	return Publish(ctx, fmt.Sprintf("events-v1:channel-emotes:%s", channel), event)
+}
+
+// GetChannelEmotesEvents returns the events of a channel which happened after the specified event ID
+func GetChannelEmotesEvents(ctx context.Context, channel string, after string) ([]EventApiV1ChannelEmotes, error) {
+	messages, err := Client.XRange(ctx, fmt.Sprintf("events-v1:channel-emotes:%s:history", channel), after, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := []EventApiV1ChannelEmotes{}
+	for _, msg := range messages {
+		if msg.ID == after {
+			continue
+		}
+
+		data, ok := msg.Values["data"].(string)
+		if !ok {
+			log.WithField("resp", msg.Values).Error("redis bad resp expected string")
+			continue
+		}
+
+		event := EventApiV1ChannelEmotes{}
+		if err := json.UnmarshalFromString(data, &event); err != nil {
+			return nil, err
+		}
+		event.EventID = msg.ID
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -1,21 +1,38 @@
-local key = ARGV[1]
-local expire = tonumber(ARGV[2])
-local limit = tonumber(ARGV[3])
-local by = tonumber(ARGV[4])
+-- Count a request against several windows at once, such as a burst and a sustained window.
+-- The request is only counted if it fits in all of them
+local by = tonumber(ARGV[1])
+local windows = {}
+local allowed = 1
 
-local exists = redis.call("EXISTS", "rl:" .. key)
+for i = 2, #ARGV, 3 do
+    local key = "rl:" .. ARGV[i]
+    local expire = tonumber(ARGV[i + 1])
+    local limit = tonumber(ARGV[i + 2])
 
-local count = redis.call("INCRBY", "rl:" .. key, by)
+    local count = tonumber(redis.call("GET", key) or "0")
+    local ttl = redis.call("TTL", key)
+    if ttl < 0 then
+        ttl = expire
+    end
+    if count + by > limit then
+        allowed = 0
+    end
 
-if exists == 0 then
-    redis.call("EXPIRE", "rl:" .. key, expire)
-    return {count, expire}
+    windows[#windows + 1] = {key, expire, count, ttl}
 end
 
-local ttl = redis.call("TTL", "rl:" .. key)
+local result = {allowed}
+for _, w in ipairs(windows) do
+    local count = w[3]
+    if allowed == 1 then
+        count = redis.call("INCRBY", w[1], by)
+        if redis.call("TTL", w[1]) < 0 then
+            redis.call("EXPIRE", w[1], w[2])
+        end
+    end
 
-if count > limit then
-    return {redis.call("DECRBY", "rl:" .. key, by), ttl, 1}
+    result[#result + 1] = count
+    result[#result + 1] = w[4]
 end
 
-return {count, ttl, 0}
+return result
@@ -0,0 +1,26 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// GetPersistedQuery returns the text of a query persisted by its SHA-256 hash, renewing its expiry.
+// Returns ErrNil if no query was persisted with this hash
+func GetPersistedQuery(ctx context.Context, hash string, ttl time.Duration) (string, error) {
+	key := fmt.Sprintf("gql:persisted-queries:%s", hash)
+
+	query, err := Client.Get(ctx, key).Result()
+	if err != nil {
+		return "", err
+	}
+
+	Client.Expire(ctx, key, ttl)
+	return query, nil
+}
+
+// SetPersistedQuery persists the text of a query by its SHA-256 hash
+func SetPersistedQuery(ctx context.Context, hash string, query string, ttl time.Duration) error {
+	return Client.Set(ctx, fmt.Sprintf("gql:persisted-queries:%s", hash), query, ttl).Err()
+}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	log "github.com/sirupsen/logrus"
@@ -67,6 +68,7 @@ type PubSubPayloadUserEmotes struct {
 }
 
 type EventApiV1ChannelEmotes struct {
+	EventID string                        `json:"event_id,omitempty"`
 	Channel string                        `json:"channel"`
 	EmoteID string                        `json:"emote_id"`
 	Name    string                        `json:"name"`
@@ -93,3 +95,21 @@ type EventApiV1ChannelEmotesEmoteOwner struct {
 	DisplayName string `json:"display_name"`
 	Login       string `json:"login"`
 }
+
+type EventApiV1EmoteStatus struct {
+	EmoteID string `json:"emote_id"`
+	Status  int32  `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type PubSubPayloadRoles struct {
+	Action string `json:"action"`
+	RoleID string `json:"role_id"`
+}
+
+type PubSubPayloadBans struct {
+	Action   string    `json:"action"`
+	UserID   string    `json:"user_id"`
+	Reason   string    `json:"reason,omitempty"`
+	ExpireAt time.Time `json:"expire_at"`
+}
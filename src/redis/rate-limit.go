@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RateLimitWindow is a counter of requests, which resets once its period has passed since the first request
+type RateLimitWindow struct {
+	Key    string
+	Period time.Duration
+	Limit  int32
+}
+
+// RateLimitCount is the state of a window, after a request was counted against it
+type RateLimitCount struct {
+	Count int64
+	// Seconds until the window resets
+	TTL int64
+}
+
+// RateLimit counts a request against the windows, if it fits in all of them.
+// Returns whether it did, and the state of each window
+func RateLimit(ctx context.Context, by int32, windows []RateLimitWindow) (bool, []RateLimitCount, error) {
+	// Ensure script
+	scriptExists, err := Client.ScriptExists(ctx, RateLimitScriptSHA1).Result()
+	if err != nil {
+		return false, nil, err
+	}
+	if !scriptExists[0] {
+		if err := ReloadScripts(); err != nil {
+			return false, nil, err
+		}
+		log.Info("ratelimit, redis: reloaded scripts")
+	}
+
+	args := []interface{}{by}
+	for _, w := range windows {
+		args = append(args, w.Key, int64(w.Period.Seconds()), w.Limit)
+	}
+	result, err := Client.EvalSha(ctx, RateLimitScriptSHA1, []string{}, args...).Result()
+	if err != nil {
+		return false, nil, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 1+len(windows)*2 {
+		return false, nil, fmt.Errorf("unexpected rate limit script reply: %v", result)
+	}
+	counts := make([]RateLimitCount, len(windows))
+	for i := range counts {
+		counts[i].Count, _ = values[1+i*2].(int64)
+		counts[i].TTL, _ = values[2+i*2].(int64)
+	}
+
+	allowed, _ := values[0].(int64)
+	return allowed == 1, counts, nil
+}
+
+// GetRateLimitCounts returns the state of windows without counting a request against them
+func GetRateLimitCounts(ctx context.Context, keys []string) ([]RateLimitCount, error) {
+	pipe := Client.Pipeline()
+	gets := make([]*StringCmd, len(keys))
+	ttls := make([]*DurationCmd, len(keys))
+	for i, key := range keys {
+		gets[i] = pipe.Get(ctx, "rl:"+key)
+		ttls[i] = pipe.TTL(ctx, "rl:"+key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != ErrNil {
+		return nil, err
+	}
+
+	counts := make([]RateLimitCount, len(keys))
+	for i := range keys {
+		counts[i].Count, _ = gets[i].Int64()
+		if ttl := ttls[i].Val(); ttl > 0 {
+			counts[i].TTL = int64(ttl.Seconds())
+		}
+	}
+
+	return counts, nil
+}
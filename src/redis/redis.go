@@ -3,6 +3,8 @@ package redis
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -38,8 +40,12 @@ func init() {
 			msg     *redis.Message
 			payload []byte
 		)
+		var ok bool
 		for {
-			msg = <-ch
+			msg, ok = <-ch
+			if !ok {
+				return // Closed on shutdown
+			}
 			payload = []byte(msg.Payload) // dont change we want to copy the memory due to concurrency.
 			subsMtx.Lock()
 			for _, s := range subs[msg.Channel] {
@@ -141,6 +147,51 @@ type redisSub struct {
 	ch chan []byte
 }
 
+// Close the subscription and the client, once the server is shutting down
+func Close() error {
+	if err := sub.Close(); err != nil {
+		log.WithError(err).Error("redis, failed to close subscription")
+	}
+
+	return Client.Close()
+}
+
+// CheckScripts ensures every lua script is loaded in redis, returning an error naming those which are missing
+func CheckScripts(ctx context.Context) error {
+	scripts := map[string]string{
+		"token-consumer":                tokenConsumerLuaScriptSHA1,
+		"get-cache":                     getCacheLuaScriptSHA1,
+		"set-cache":                     setCacheLuaScriptSHA1,
+		"invalidate-cache":              invalidateCacheLuaScriptSHA1,
+		"invalidate-common-index-cache": invalidateCommonIndexCacheLuaScriptSHA1,
+		"rate-limit":                    RateLimitScriptSHA1,
+	}
+	names := make([]string, 0, len(scripts))
+	hashes := make([]string, 0, len(scripts))
+	for name, sha1 := range scripts {
+		names = append(names, name)
+		hashes = append(hashes, sha1)
+	}
+
+	exists, err := Client.ScriptExists(ctx, hashes...).Result()
+	if err != nil {
+		return err
+	}
+
+	missing := []string{}
+	for i, ok := range exists {
+		if !ok {
+			missing = append(missing, names[i])
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing scripts: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
 var lockerClient *redislock.Client
 
 func GetLocker() *redislock.Client {
@@ -155,6 +206,8 @@ type Message = redis.Message
 
 type StringCmd = redis.StringCmd
 
+type DurationCmd = redis.DurationCmd
+
 type StringStringMapCmd = redis.StringStringMapCmd
 
 type PubSub = redis.PubSub
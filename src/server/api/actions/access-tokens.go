@@ -0,0 +1,132 @@
+package actions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// How long to wait before recording the use of a token again
+const accessTokenUseInterval = time.Minute
+
+// Create: Issue a new access token acting as a user.
+// Returns the raw token, which is only ever available here since just its hash is stored
+func (*accessTokens) Create(ctx context.Context, user *datastructure.User, name string, scopes []datastructure.AccessTokenScope) (string, *datastructure.AccessToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	raw := datastructure.AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	token := &datastructure.AccessToken{
+		UserID:    user.ID,
+		Name:      name,
+		Scopes:    scopes,
+		TokenHash: hashAccessToken(raw),
+		CreatedAt: time.Now(),
+	}
+	res, err := mongo.Collection(mongo.CollectionNameAccessTokens).InsertOne(ctx, token)
+	if err != nil {
+		return "", nil, err
+	}
+	token.ID = res.InsertedID.(primitive.ObjectID)
+
+	return raw, token, nil
+}
+
+// List: Get all access tokens acting as a user
+func (*accessTokens) List(ctx context.Context, userID primitive.ObjectID) ([]*datastructure.AccessToken, error) {
+	tokens := []*datastructure.AccessToken{}
+	cur, err := mongo.Collection(mongo.CollectionNameAccessTokens).Find(ctx, bson.M{
+		"user_id": userID,
+	}, options.Find().SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Get: Get an access token by its ID
+func (*accessTokens) Get(ctx context.Context, id primitive.ObjectID) (*datastructure.AccessToken, error) {
+	token := &datastructure.AccessToken{}
+	if err := mongo.Collection(mongo.CollectionNameAccessTokens).FindOne(ctx, bson.M{
+		"_id": id,
+	}).Decode(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Count: Get the amount of access tokens acting as a user
+func (*accessTokens) Count(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return mongo.Collection(mongo.CollectionNameAccessTokens).CountDocuments(ctx, bson.M{
+		"user_id": userID,
+	})
+}
+
+// Revoke: Delete one of a user's access tokens, returning it if it existed
+func (*accessTokens) Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*datastructure.AccessToken, error) {
+	token := &datastructure.AccessToken{}
+	if err := mongo.Collection(mongo.CollectionNameAccessTokens).FindOneAndDelete(ctx, bson.M{
+		"_id":     id,
+		"user_id": userID,
+	}).Decode(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// Authenticate: Find the access token matching a raw token, and record its use
+func (*accessTokens) Authenticate(ctx context.Context, raw string) (*datastructure.AccessToken, error) {
+	token := &datastructure.AccessToken{}
+	if err := mongo.Collection(mongo.CollectionNameAccessTokens).FindOne(ctx, bson.M{
+		"token_hash": hashAccessToken(raw),
+	}).Decode(token); err != nil {
+		return nil, err
+	}
+
+	// Avoid a write on every request from busy bots
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > accessTokenUseInterval {
+		if _, err := mongo.Collection(mongo.CollectionNameAccessTokens).UpdateOne(ctx, bson.M{
+			"_id": token.ID,
+		}, bson.M{
+			"$set": bson.M{"last_used_at": now},
+		}); err != nil {
+			return nil, err
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
+
+// GetLimit: Get the maximum amount of access tokens a user can hold
+func (*accessTokens) GetLimit() int64 {
+	if limit := configure.Config.GetInt64("limits.meta.access_tokens"); limit > 0 {
+		return limit
+	}
+
+	return 25
+}
+
+func hashAccessToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,15 +2,31 @@ package actions
 
 import (
 	"context"
+	"sync"
 
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	jsoniter "github.com/json-iterator/go"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
 type emotes struct{}
 
 var Emotes emotes = emotes{}
 
+type emoteSets struct{}
+
+var EmoteSets emoteSets = emoteSets{}
+
+type accessTokens struct{}
+
+var AccessTokens accessTokens = accessTokens{}
+
+type roles struct{}
+
+var Roles roles = roles{}
+
 type notifications struct{}
 
 type NotificationBuilder struct {
@@ -46,9 +62,10 @@ type UserBuilder struct {
 var Users users = users{}
 
 type bans struct {
-	BannedUsers map[primitive.ObjectID]*datastructure.Ban
+	mtx         sync.RWMutex
+	bannedUsers map[primitive.ObjectID]*datastructure.Ban
 }
 
 var Bans *bans = &bans{
-	BannedUsers: map[primitive.ObjectID]*datastructure.Ban{},
+	bannedUsers: map[primitive.ObjectID]*datastructure.Ban{},
 }
@@ -1,16 +1,24 @@
 package actions
 
 import (
+	"bytes"
 	"context"
+	"sort"
 	"time"
 
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// FetchBans gets the current active bans stored in database and store them in memory
+// The redis channel on which bans and unbans are broadcast to all instances
+const BansUpdateChannel = "bans:updated"
+
+// FetchBans gets the current active bans stored in database and store them in memory.
+// This replaces the bans in memory, so it also reconciles bans missed from other instances
 func (b *bans) FetchBans(ctx context.Context) error {
 	banList := []*datastructure.Ban{}
 	cur, err := mongo.Collection(mongo.CollectionNameBans).Find(ctx, bson.M{
@@ -27,24 +35,185 @@ func (b *bans) FetchBans(ctx context.Context) error {
 		return err
 	}
 
+	bannedUsers := make(map[primitive.ObjectID]*datastructure.Ban, len(banList))
 	for _, ban := range banList {
 		userID := ban.UserID
 
-		b.BannedUsers[*userID] = ban
+		bannedUsers[*userID] = ban
 	}
 
+	b.mtx.Lock()
+	b.bannedUsers = bannedUsers
+	b.mtx.Unlock()
+
 	return nil
 }
 
 func (b *bans) IsUserBanned(id primitive.ObjectID) (bool, string) {
-	ban, ok := b.BannedUsers[id]
+	b.mtx.RLock()
+	ban, ok := b.bannedUsers[id]
+	b.mtx.RUnlock()
 	if !ok {
 		return false, ""
 	}
 	if time.Now().After(ban.ExpireAt) {
-		delete(b.BannedUsers, id)
+		b.mtx.Lock()
+		if b.bannedUsers[id] == ban {
+			delete(b.bannedUsers, id)
+		}
+		b.mtx.Unlock()
 		return false, ""
 	}
 
 	return true, ban.Reason
 }
+
+// UserIDs: Get the IDs of the users currently banned, in order
+func (b *bans) UserIDs() []primitive.ObjectID {
+	now := time.Now()
+	b.mtx.RLock()
+	ids := make([]primitive.ObjectID, 0, len(b.bannedUsers))
+	for id, ban := range b.bannedUsers {
+		if !now.After(ban.ExpireAt) {
+			ids = append(ids, id)
+		}
+	}
+	b.mtx.RUnlock()
+
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+	return ids
+}
+
+// Count: Get the amount of bans held in memory
+func (b *bans) Count() int {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	return len(b.bannedUsers)
+}
+
+// Add: Hold a ban in memory and broadcast it to the other instances
+func (b *bans) Add(ctx context.Context, ban *datastructure.Ban) {
+	b.mtx.Lock()
+	b.bannedUsers[*ban.UserID] = ban
+	b.mtx.Unlock()
+
+	if err := redis.Publish(ctx, BansUpdateChannel, redis.PubSubPayloadBans{
+		Action:   "BAN",
+		UserID:   ban.UserID.Hex(),
+		Reason:   ban.Reason,
+		ExpireAt: ban.ExpireAt,
+	}); err != nil {
+		log.WithError(err).Error("redis")
+	}
+}
+
+// Remove: Drop a user's ban from memory and broadcast it to the other instances
+func (b *bans) Remove(ctx context.Context, userID primitive.ObjectID) {
+	b.mtx.Lock()
+	delete(b.bannedUsers, userID)
+	b.mtx.Unlock()
+
+	if err := redis.Publish(ctx, BansUpdateChannel, redis.PubSubPayloadBans{
+		Action: "UNBAN",
+		UserID: userID.Hex(),
+	}); err != nil {
+		log.WithError(err).Error("redis")
+	}
+}
+
+// Listen: Apply bans and unbans broadcast by other instances, until the context is cancelled
+func (b *bans) Listen(ctx context.Context) {
+	ch := make(chan []byte, 1)
+	redis.Subscribe(ctx, ch, BansUpdateChannel)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-ch:
+			payload := redis.PubSubPayloadBans{}
+			if err := json.Unmarshal(msg, &payload); err != nil {
+				log.WithError(err).Error("bans, bad update payload")
+				continue
+			}
+			userID, err := primitive.ObjectIDFromHex(payload.UserID)
+			if err != nil {
+				log.WithError(err).WithField("user_id", payload.UserID).Error("bans, bad update payload")
+				continue
+			}
+
+			b.mtx.Lock()
+			switch payload.Action {
+			case "BAN":
+				b.bannedUsers[userID] = &datastructure.Ban{
+					UserID:   &userID,
+					Reason:   payload.Reason,
+					ExpireAt: payload.ExpireAt,
+				}
+			case "UNBAN":
+				delete(b.bannedUsers, userID)
+			}
+			b.mtx.Unlock()
+		}
+	}
+}
+
+// ClearExpired: Lift bans which expired, recording an unban in the audit log for each.
+// Returns the amount of bans lifted
+func (b *bans) ClearExpired(ctx context.Context) (int, error) {
+	banList := []*datastructure.Ban{}
+	cur, err := mongo.Collection(mongo.CollectionNameBans).Find(ctx, bson.M{
+		"expire_at": bson.M{
+			"$lte": time.Now(),
+			"$ne":  time.Time{},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := cur.All(ctx, &banList); err != nil {
+		return 0, err
+	}
+
+	reason := "Ban expired"
+	count := 0
+	for _, ban := range banList {
+		// Lifted bans have their expiry cleared, the same as an unban
+		res, err := mongo.Collection(mongo.CollectionNameBans).UpdateOne(ctx, bson.M{
+			"_id":       ban.ID,
+			"expire_at": ban.ExpireAt,
+		}, bson.M{
+			"$set": bson.M{"expire_at": time.Time{}},
+		})
+		if err != nil {
+			return count, err
+		}
+		if res.ModifiedCount == 0 {
+			continue // Lifted by someone else in the meantime
+		}
+		count++
+
+		if _, err := mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+			Type:      datastructure.AuditLogTypeUserUnban,
+			CreatedBy: primitive.NilObjectID,
+			Target:    &datastructure.Target{ID: ban.UserID, Type: "users"},
+			Changes:   nil,
+			Reason:    &reason,
+		}); err != nil {
+			log.WithError(err).Error("mongo")
+		}
+
+		// Only broadcast if the user has no other active ban
+		if active, err := mongo.Collection(mongo.CollectionNameBans).CountDocuments(ctx, bson.M{
+			"user_id":   ban.UserID,
+			"expire_at": bson.M{"$gt": time.Now()},
+		}); err != nil || active == 0 {
+			b.Remove(ctx, *ban.UserID)
+		}
+	}
+
+	return count, nil
+}
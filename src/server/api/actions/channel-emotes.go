@@ -0,0 +1,125 @@
+package actions
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ChannelEmotes: The emotes of a channel's active set, with their aliases as names
+type ChannelEmotes struct {
+	Channel *datastructure.User
+	Emotes  []*datastructure.Emote
+}
+
+// GetChannelEmotesLimit: Get the maximum amount of channels whose emotes can be fetched at once
+func (*emotes) GetChannelEmotesLimit() int {
+	if limit := configure.Config.GetInt("limits.meta.bulk_channel_emotes"); limit > 0 {
+		return limit
+	}
+
+	return 100
+}
+
+// GetChannelEmotes: Get the emotes of several channels by Twitch ID, login or YouTube ID, mapped by the identifiers.
+// However many channels are asked for, they are resolved with one query each for users, role entitlements, emote sets and emotes.
+// Unknown channels are omitted, and banned channels have no emotes
+func (*emotes) GetChannelEmotes(ctx context.Context, identifiers []string) (map[string]*ChannelEmotes, error) {
+	result := make(map[string]*ChannelEmotes, len(identifiers))
+	ids, logins := []string{}, []string{}
+	for _, s := range identifiers {
+		if s != "" {
+			ids = append(ids, s)
+			logins = append(logins, strings.ToLower(s))
+		}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+	channels, err := Users.GetManyWithRoles(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"id": bson.M{"$in": ids}},
+			bson.M{"login": bson.M{"$in": logins}},
+			bson.M{"yt_id": bson.M{"$in": ids}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	emoteIDs, err := EmoteSets.GetActiveEmoteIDsMany(ctx, channels)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the emotes of all channels at once
+	allIDs := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, ids := range emoteIDs {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				allIDs = append(allIDs, id)
+			}
+		}
+	}
+	emotes := []*datastructure.Emote{}
+	if len(allIDs) > 0 {
+		cur, err := mongo.Collection(mongo.CollectionNameEmotes).Find(ctx, bson.M{
+			"_id": bson.M{"$in": allIDs},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := cur.All(ctx, &emotes); err != nil {
+			return nil, err
+		}
+	}
+	emoteMap := make(map[primitive.ObjectID]*datastructure.Emote, len(emotes))
+	for _, e := range emotes {
+		emoteMap[e.ID] = e
+	}
+
+	for _, channel := range channels {
+		ce := &ChannelEmotes{
+			Channel: channel,
+			Emotes:  []*datastructure.Emote{},
+		}
+
+		if banned, _ := Bans.IsUserBanned(channel.ID); !banned {
+			zeroWidthOK := channel.HasPermission(datastructure.RolePermissionUseZeroWidthEmote)
+			channelEmotes := []*datastructure.Emote{}
+			for _, id := range emoteIDs[channel.ID] {
+				e, ok := emoteMap[id]
+				if !ok {
+					continue
+				}
+				// Omit zerowidth emote if the channel lacks permission to use those
+				if !zeroWidthOK && utils.BitField.HasBits(int64(e.Visibility), int64(datastructure.EmoteVisibilityZeroWidth)) {
+					continue
+				}
+
+				// Aliases are set as names, so each channel gets its own copy
+				emote := *e
+				channelEmotes = append(channelEmotes, &emote)
+			}
+
+			channel.Emotes = &channelEmotes
+			ce.Emotes = datastructure.UserUtil.GetAliasedEmotes(channel)
+		}
+
+		for _, s := range ids {
+			if s == channel.TwitchID || strings.ToLower(s) == channel.Login || (channel.YouTubeID != "" && s == channel.YouTubeID) {
+				result[s] = ce
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package actions
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Cursor: The position of the last item of a page, which the next page continues after.
+// Items are ordered by a sort value, with ties broken by their ID
+type Cursor struct {
+	ID primitive.ObjectID
+	// The sort value of the item, if not ordered by ID alone
+	Value *int64
+}
+
+// Page: Where a page of a paginated list stands in the full list
+type Page struct {
+	HasNextPage bool
+	// The cursor of the last item of the page, empty if the page is empty
+	EndCursor  string
+	TotalCount int64
+}
+
+// Encode a cursor as an opaque string
+func (c Cursor) Encode() string {
+	b := make([]byte, len(c.ID), len(c.ID)+8)
+	copy(b, c.ID[:])
+	if c.Value != nil {
+		b = b[:len(c.ID)+8]
+		binary.BigEndian.PutUint64(b[len(c.ID):], uint64(*c.Value))
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor: Decode a cursor made by Cursor.Encode
+func DecodeCursor(s string) (Cursor, bool) {
+	c := Cursor{}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || (len(b) != len(c.ID) && len(b) != len(c.ID)+8) {
+		return c, false
+	}
+
+	copy(c.ID[:], b)
+	if len(b) > len(c.ID) {
+		v := int64(binary.BigEndian.Uint64(b[len(c.ID):]))
+		c.Value = &v
+	}
+	return c, !c.ID.IsZero()
+}
+
+// Filter: The filter matching the items after the cursor, for items sorted by field then ID in the same direction.
+// A cursor without a sort value stands for an item missing the field, such items come first in ascending order
+func (c Cursor) Filter(field string, direction int32) bson.M {
+	op := "$gt"
+	if direction < 0 {
+		op = "$lt"
+	}
+
+	if field == "" || field == "_id" {
+		return bson.M{"_id": bson.M{op: c.ID}}
+	}
+	if c.Value == nil {
+		after := bson.A{bson.M{field: nil, "_id": bson.M{op: c.ID}}}
+		if direction > 0 {
+			after = append(after, bson.M{field: bson.M{"$ne": nil}})
+		}
+		return bson.M{"$or": after}
+	}
+
+	after := bson.A{
+		bson.M{field: bson.M{op: *c.Value}},
+		bson.M{field: *c.Value, "_id": bson.M{op: c.ID}},
+	}
+	if direction < 0 {
+		after = append(after, bson.M{field: nil})
+	}
+	return bson.M{"$or": after}
+}
+
+// Append the stages of a pipeline which sort its items and cut the page after the cursor, with one extra item to tell if there is a next page.
+// Items are sorted by a field then by ID, or by ID alone if field is empty. The field should be indexed along with _id,
+// as the sort must not run in memory on large collections
+func paginate(pipeline mongo.Pipeline, field string, direction int32, after *Cursor, limit int64) mongo.Pipeline {
+	if direction == 0 {
+		direction = -1
+	}
+
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if field != "" && field != "_id" {
+		sort = append(bson.D{{Key: field, Value: direction}}, sort...)
+	}
+	if after != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: after.Filter(field, direction)}})
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$sort", Value: sort}},
+		bson.D{{Key: "$limit", Value: limit + 1}},
+	)
+}
@@ -6,10 +6,10 @@ import (
 	"sync"
 	"time"
 
-	"github.com/SevenTV/ServerGo/src/aws"
 	"github.com/SevenTV/ServerGo/src/configure"
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/storage"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -21,6 +21,7 @@ func (*emotes) Delete(ctx context.Context, emote *datastructure.Emote) error {
 		"$set": bson.M{
 			"status":             datastructure.EmoteStatusDeleted,
 			"last_modified_date": time.Now(),
+			"edited_at":          time.Now(),
 		},
 	})
 	if err != nil {
@@ -35,7 +36,7 @@ func (*emotes) Delete(ctx context.Context, emote *datastructure.Emote) error {
 		go func(i int) {
 			defer wg.Done()
 			obj := fmt.Sprintf("emote/%s", emote.ID.Hex())
-			err := aws.Expire(configure.Config.GetString("aws_cdn_bucket"), obj, i)
+			err := storage.Expire(configure.Config.GetString("aws_cdn_bucket"), obj, i)
 			if err != nil {
 				log.WithError(err).WithField("obj", obj).Error("aws")
 			}
@@ -52,6 +53,16 @@ func (*emotes) Delete(ctx context.Context, emote *datastructure.Emote) error {
 	if err != nil {
 		log.WithError(err).Error("mongo")
 	}
+	_, err = mongo.Collection(mongo.CollectionNameEmoteSets).UpdateMany(ctx, bson.M{
+		"emotes": emote.ID,
+	}, bson.M{
+		"$pull": bson.M{
+			"emotes": emote.ID,
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
 
 	wg.Wait()
 
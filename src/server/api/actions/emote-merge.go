@@ -124,6 +124,20 @@ func (*emotes) MergeEmote(ctx context.Context, opts MergeEmoteOptions) (*datastr
 		logInfo.Infof("Updated no users during merger of Emote(id=%v) into Emote(id=%v)", oldEmote.ID.Hex(), newEmote.ID.Hex())
 	}
 
+	// Switch the emote in emote sets, including those not in use
+	if _, err := mongo.Collection(mongo.CollectionNameEmoteSets).UpdateMany(ctx, bson.M{
+		"emotes": oldEmote.ID,
+	}, bson.M{
+		"$set": bson.M{"emotes.$[filter]": newEmote.ID},
+	}, options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{
+			bson.M{"filter": oldEmote.ID},
+		},
+	})); err != nil {
+		log.WithError(err).Error("mongo, failed to update emote sets during emote merger")
+		return nil, err
+	}
+
 	// Send notifications
 	{
 		// Send a notification to the old emote's owner that their emote was merged
@@ -183,7 +197,9 @@ func (*emotes) MergeEmote(ctx context.Context, opts MergeEmoteOptions) (*datastr
 		}()
 
 		// Send to Discord
-		go discord.SendEmoteMerge(oldEmote, newEmote, *opts.Actor, int32(len(switchedChannels)), opts.Reason)
+		discord.Go(func() {
+			discord.SendEmoteMerge(oldEmote, newEmote, *opts.Actor, int32(len(switchedChannels)), opts.Reason)
+		})
 	}
 
 	// Now we will delete the old emote
@@ -0,0 +1,267 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/discord"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/storage"
+	"github.com/SevenTV/ServerGo/src/utils"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/gographics/imagick.v3/imagick"
+)
+
+const (
+	EmoteProcessingQueueKey      = "emote-processing:queue"      // Jobs waiting for a worker
+	EmoteProcessingInProgressKey = "emote-processing:processing" // Jobs claimed by a worker
+)
+
+// An upload waiting to be resized and uploaded to the CDN
+type EmoteProcessingJob struct {
+	EmoteID   primitive.ObjectID `json:"emote_id"`
+	ActorID   primitive.ObjectID `json:"actor_id"`
+	UploadKey string             `json:"upload_key"` // The storage key of the original file, readable by the workers of any pod
+	APNG      bool               `json:"apng"`       // Whether the original file is an animated PNG
+	Width     int                `json:"width"`      // The original file's width in pixels
+	Height    int                `json:"height"`     // The original file's height in pixels
+	Attempts  int                `json:"attempts"`   // The amount of times the job was requeued after being orphaned
+}
+
+// EnqueueProcessing: Add an uploaded emote to the processing queue
+func (*emotes) EnqueueProcessing(ctx context.Context, job EmoteProcessingJob) error {
+	j, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return redis.Client.LPush(ctx, EmoteProcessingQueueKey, j).Err()
+}
+
+// DeleteUpload: Delete the original file of a job, once it is no longer going to be processed
+func (*emotes) DeleteUpload(job EmoteProcessingJob) {
+	if job.UploadKey == "" {
+		return
+	}
+	if err := storage.DeleteFile(configure.Config.GetString("aws_cdn_bucket"), job.UploadKey, false); err != nil {
+		log.WithError(err).WithField("key", job.UploadKey).Error("storage")
+	}
+}
+
+// ProcessEmote: Resize an uploaded emote to all of its scales and upload them to the CDN.
+// The emote goes live once all files were uploaded
+func (e *emotes) ProcessEmote(ctx context.Context, job EmoteProcessingJob) error {
+	// The job may have been uploaded by another pod, so the original file is read from storage
+	og, err := storage.DownloadFile(configure.Config.GetString("aws_cdn_bucket"), job.UploadKey)
+	if err != nil {
+		log.WithError(err).Error("storage")
+		return fmt.Errorf("The uploaded file is no longer available")
+	}
+
+	dir := fmt.Sprintf("%s/%s", configure.Config.GetString("temp_file_store"), job.EmoteID.Hex())
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		log.WithError(err).Error("mkdir")
+		return fmt.Errorf("Could not process the emote")
+	}
+	defer os.RemoveAll(dir)
+
+	readPath := fmt.Sprintf("%s/og", dir)
+	if err := os.WriteFile(readPath, og, 0644); err != nil {
+		log.WithError(err).Error("write")
+		return fmt.Errorf("Could not process the emote")
+	}
+	if job.APNG {
+		readPath = "apng:" + readPath // Without the prefix only the default image would be read
+	}
+
+	// Look for existing emotes which look the same
+	if err := e.DedupUpload(ctx, job, readPath); err != nil {
+		return err
+	}
+
+	files := datastructure.EmoteUtil.GetFilesMeta(dir)
+	mime := "image/webp"
+
+	sizeX := [4]int16{0, 0, 0, 0}
+	sizeY := [4]int16{0, 0, 0, 0}
+	// Resize the frame(s)
+	for i, file := range files {
+		sizes := strings.Split(file[2], "x")
+		maxWidth, _ := strconv.ParseFloat(sizes[0], 4)
+		maxHeight, _ := strconv.ParseFloat(sizes[1], 4)
+		quality, _ := strconv.Atoi(file[3])
+
+		// Get calculed ratio for the size
+		width, height := utils.GetSizeRatio(
+			[]float64{float64(job.Width), float64(job.Height)},
+			[]float64{maxWidth, maxHeight},
+		)
+		sizeX[i] = int16(width)
+		sizeY[i] = int16(height)
+
+		if err := resizeEmote(readPath, file[0]+".webp", uint(width), uint(height), uint(quality)); err != nil {
+			return err
+		}
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(files))
+
+	erroredMtx := sync.Mutex{}
+	errored := false
+	setErrored := func() {
+		erroredMtx.Lock()
+		errored = true
+		erroredMtx.Unlock()
+	}
+	for _, path := range files {
+		go func(path []string) {
+			defer wg.Done()
+			data, err := os.ReadFile(path[0] + ".webp")
+			if err != nil {
+				log.WithError(err).Error("read")
+				setErrored()
+				return
+			}
+
+			if err := storage.UploadFile(configure.Config.GetString("aws_cdn_bucket"), fmt.Sprintf("emote/%s/%s", job.EmoteID.Hex(), path[1]), data, &mime); err != nil {
+				log.WithError(err).Error("aws")
+				setErrored()
+			}
+		}(path)
+	}
+
+	wg.Wait()
+
+	if errored {
+		return fmt.Errorf("Could not upload the emote's files")
+	}
+
+	if _, err := mongo.Collection(mongo.CollectionNameEmotes).UpdateOne(ctx, bson.M{
+		"_id": job.EmoteID,
+	}, bson.M{
+		"$set": bson.M{
+			"width":     sizeX,
+			"height":    sizeY,
+			"edited_at": time.Now(),
+		},
+	}); err != nil {
+		return err
+	}
+	if err := e.SetEmoteStatus(ctx, job.EmoteID, datastructure.EmoteStatusLive, ""); err != nil {
+		return err
+	}
+
+	// Notify the activity feed
+	var emote datastructure.Emote
+	var actor datastructure.User
+	if err := mongo.Collection(mongo.CollectionNameEmotes).FindOne(ctx, bson.M{"_id": job.EmoteID}).Decode(&emote); err != nil {
+		log.WithError(err).Error("mongo")
+		return nil
+	}
+	if err := mongo.Collection(mongo.CollectionNameUsers).FindOne(ctx, bson.M{"_id": job.ActorID}).Decode(&actor); err != nil {
+		log.WithError(err).Error("mongo")
+		return nil
+	}
+
+	discord.Go(func() { discord.SendEmoteCreate(emote, actor) })
+	return nil
+}
+
+// SetEmoteStatus: Update the status of an emote which is being processed and notify clients waiting for it.
+// Emotes which are no longer processing, such as ones another worker already finished, are left as they are
+func (*emotes) SetEmoteStatus(ctx context.Context, id primitive.ObjectID, status int32, message string) error {
+	update := bson.M{"$set": bson.M{"status": status, "edited_at": time.Now()}}
+	if message != "" {
+		update["$set"].(bson.M)["status_message"] = message
+	} else {
+		update["$unset"] = bson.M{"status_message": ""}
+	}
+
+	res, err := mongo.Collection(mongo.CollectionNameEmotes).UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": datastructure.EmoteStatusProcessing,
+	}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return nil
+	}
+
+	return redis.Publish(ctx, fmt.Sprintf("events-v1:emote-status:%s", id.Hex()), redis.EventApiV1EmoteStatus{
+		EmoteID: id.Hex(),
+		Status:  status,
+		Message: message,
+	})
+}
+
+// Resize all frames of the original file and write them as WebP
+func resizeEmote(readPath string, outFile string, width, height, quality uint) error {
+	// Create new boundaries for frames
+	mw := imagick.NewMagickWand() // Get magick wand & read the original image
+	if err := mw.SetResourceLimit(imagick.RESOURCE_MEMORY, 500); err != nil {
+		log.WithError(err).Error("SetResourceLimit")
+	}
+	if err := mw.ReadImage(readPath); err != nil {
+		mw.Destroy()
+		return fmt.Errorf("Input File Not Readable: %s", err)
+	}
+
+	// Merge all frames with coalesce
+	aw := mw.CoalesceImages()
+	if err := aw.SetResourceLimit(imagick.RESOURCE_MEMORY, 500); err != nil {
+		log.WithError(err).Error("SetResourceLimit")
+	}
+	mw.Destroy()
+	defer aw.Destroy()
+
+	// Set delays
+	mw = imagick.NewMagickWand()
+	if err := mw.SetResourceLimit(imagick.RESOURCE_MEMORY, 500); err != nil {
+		log.WithError(err).Error("SetResourceLimit")
+	}
+	defer mw.Destroy()
+
+	// Add each frame to our animated image
+	mw.ResetIterator()
+	for ind := 0; ind < int(aw.GetNumberImages()); ind++ {
+		aw.SetIteratorIndex(ind)
+		img := aw.GetImage()
+
+		if err := img.ResizeImage(width, height, imagick.FILTER_LANCZOS); err != nil {
+			log.WithError(err).Errorf("ResizeImage i=%v", ind)
+			continue
+		}
+		if err := mw.AddImage(img); err != nil {
+			log.WithError(err).Errorf("AddImage i=%v", ind)
+		}
+		img.Destroy()
+	}
+
+	// Done - convert to WEBP
+	if err := mw.SetImageCompressionQuality(quality); err != nil {
+		log.WithError(err).Error("SetImageCompressionQuality")
+	}
+	if err := mw.SetImageFormat("webp"); err != nil {
+		log.WithError(err).Error("SetImageFormat")
+	}
+
+	// Write to file
+	if err := mw.WriteImages(outFile, true); err != nil {
+		log.WithError(err).Error("cmd")
+		return fmt.Errorf("Could not write the resized emote")
+	}
+
+	return nil
+}
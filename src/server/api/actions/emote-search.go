@@ -0,0 +1,211 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/SevenTV/ServerGo/src/cache"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var searchRegex = regexp.MustCompile(`[.*+?^${}()|[\\]\\\\]`)
+
+// SearchPattern: The case-insensitive regex pattern matching text which contains a search query
+func SearchPattern(query string) string {
+	query = strings.Trim(query, " ")
+	return fmt.Sprintf("(?i)%s", strings.ToLower(searchRegex.ReplaceAllString(query, "\\\\$0")))
+}
+
+// EmoteSearchOptions: What an emote search matches and how its results are ordered
+type EmoteSearchOptions struct {
+	// Text to look for in the names and tags of emotes
+	Query string
+	// Only search the emotes of the channel with this login
+	Channel *string
+	// "only" to search global emotes only, "hide" to leave them out
+	GlobalState string
+	// Visibility flags the emotes must have set
+	Visibility *int32
+	// Visibility flags the emotes must have clear
+	VisibilityClear *int32
+	// The widths the largest size of the emotes must be within, as [min, max]
+	WidthRange *[]int32
+	// The user searching, who may also find their own private and unlisted emotes
+	Actor *datastructure.User
+	// "popularity" or "age", the emotes are ordered by ID otherwise
+	SortBy string
+	// 1 for ascending, -1 for descending
+	SortDirection int32
+}
+
+// SearchFilter: Build the filter matching the emotes of a search
+func (*emotes) SearchFilter(ctx context.Context, opts EmoteSearchOptions) (bson.M, error) {
+	match := bson.M{
+		"status": datastructure.EmoteStatusLive,
+	}
+	if opts.Channel != nil {
+		var targetChannel *datastructure.User
+		// Find user and get their emotes
+		if err := cache.FindOne(ctx, "users", "", bson.M{"login": opts.Channel}, &targetChannel); err == nil {
+			match["_id"] = bson.M{"$in": targetChannel.EmoteIDs}
+		}
+	}
+
+	if opts.Actor == nil || !opts.Actor.HasPermission(datastructure.RolePermissionEmoteEditAll) {
+		var usrID primitive.ObjectID
+		if opts.Actor != nil {
+			usrID = opts.Actor.ID
+		}
+
+		match["$and"] = bson.A{
+			bson.M{"$or": bson.A{
+				bson.M{"visibility": bson.M{"$bitsAllClear": int32(datastructure.EmoteVisibilityPrivate | datastructure.EmoteVisibilityUnlisted)}},
+				bson.M{"owner": usrID},
+			}},
+		}
+	}
+
+	// Handle visibility filter
+	visibilityFilter := bson.M{}
+	if opts.Visibility != nil {
+		visibilityFilter["$bitsAllSet"] = *opts.Visibility
+	}
+	if opts.VisibilityClear != nil {
+		visibilityFilter["$bitsAllClear"] = *opts.VisibilityClear
+	}
+	if len(visibilityFilter) > 0 {
+		match["visibility"] = visibilityFilter
+	}
+
+	// Handle width range filter
+	if opts.WidthRange != nil {
+		if len(*opts.WidthRange) != 2 { // Error if the length wasn't 2
+			return nil, fmt.Errorf("filter.width_range must be a list with 2 integers, but the length given was %d", len(*opts.WidthRange))
+		}
+
+		list := *opts.WidthRange
+		min := list[0]
+		max := list[1]
+		if max < min {
+			return nil, fmt.Errorf("the max value cannot be smaller than the minimum value")
+		}
+
+		match["width.3"] = bson.M{
+			"$gte": min,
+			"$lte": max,
+		}
+	}
+
+	if strings.Trim(opts.Query, " ") != "" {
+		pattern := SearchPattern(opts.Query)
+		match["$or"] = bson.A{
+			bson.M{"name": bson.M{"$regex": pattern}},
+			bson.M{"tags": bson.M{"$regex": pattern}},
+		}
+	}
+
+	// If global state is specified, filter global emotes
+	switch opts.GlobalState {
+	case "only": // Only: query only global emotes
+		match["visibility"] = bson.M{"$bitsAllSet": int32(datastructure.EmoteVisibilityGlobal)}
+	case "hide": // Hide: omit global emotes from query
+		match["visibility"] = bson.M{"$bitsAllClear": int32(datastructure.EmoteVisibilityGlobal)}
+	}
+
+	return match, nil
+}
+
+// Search: Get a page of the emotes matching a search, continuing after a cursor
+func (x *emotes) Search(ctx context.Context, opts EmoteSearchOptions, limit int64, after *Cursor) ([]*datastructure.Emote, Page, error) {
+	page := Page{}
+	match, err := x.SearchFilter(ctx, opts)
+	if err != nil {
+		return nil, page, err
+	}
+
+	if page.TotalCount, err = cache.GetCollectionSize(ctx, "emotes", match); err != nil {
+		return nil, page, err
+	}
+
+	sortField := ""
+	if opts.SortBy == "popularity" {
+		sortField = "channel_count"
+	}
+	pipeline := paginate(mongo.Pipeline{
+		bson.D{{Key: "$match", Value: match}},
+	}, sortField, opts.SortDirection, after, limit)
+
+	emotes := []*datastructure.Emote{}
+	cur, err := mongo.Collection(mongo.CollectionNameEmotes).Aggregate(ctx, pipeline)
+	if err == nil {
+		err = cur.All(ctx, &emotes)
+	}
+	if err != nil {
+		return nil, page, err
+	}
+
+	if int64(len(emotes)) > limit {
+		emotes = emotes[:limit]
+		page.HasNextPage = true
+	}
+	if len(emotes) > 0 {
+		last := emotes[len(emotes)-1]
+		c := Cursor{ID: last.ID}
+		if sortField != "" && last.ChannelCount != nil {
+			v := int64(*last.ChannelCount)
+			c.Value = &v
+		}
+		page.EndCursor = c.Encode()
+	}
+
+	return emotes, page, nil
+}
+
+// GetChannels: Get a page of the users who added an emote, highest role first, continuing after a cursor.
+// Banned users are left out
+func (*emotes) GetChannels(ctx context.Context, emoteID primitive.ObjectID, limit int64, after *Cursor) ([]*datastructure.User, Page, error) {
+	page := Page{}
+	match := bson.M{"emotes": emoteID}
+	if banned := Bans.UserIDs(); len(banned) > 0 {
+		match["_id"] = bson.M{"$nin": banned}
+	}
+
+	var err error
+	if page.TotalCount, err = cache.GetCollectionSize(ctx, "users", match); err != nil {
+		return nil, page, err
+	}
+
+	pipeline := paginate(mongo.Pipeline{
+		bson.D{{Key: "$match", Value: match}},
+	}, "role_position", -1, after, limit)
+
+	users := []*datastructure.User{}
+	cur, err := mongo.Collection(mongo.CollectionNameUsers).Aggregate(ctx, pipeline)
+	if err == nil {
+		err = cur.All(ctx, &users)
+	}
+	if err != nil {
+		return nil, page, err
+	}
+
+	if int64(len(users)) > limit {
+		users = users[:limit]
+		page.HasNextPage = true
+	}
+	if len(users) > 0 {
+		last := users[len(users)-1]
+		c := Cursor{ID: last.ID}
+		if last.RolePosition != nil {
+			v := int64(*last.RolePosition)
+			c.Value = &v
+		}
+		page.EndCursor = c.Encode()
+	}
+
+	return users, page, nil
+}
@@ -0,0 +1,238 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetActiveEmoteIDs: Get the emotes of a channel's active set.
+// Channels which never created a set use their list of emotes as is
+func (*emoteSets) GetActiveEmoteIDs(ctx context.Context, channel *datastructure.User) ([]primitive.ObjectID, error) {
+	if channel.ActiveEmoteSetID == nil {
+		return channel.EmoteIDs, nil
+	}
+
+	set := &datastructure.EmoteSet{}
+	if err := mongo.Collection(mongo.CollectionNameEmoteSets).FindOne(ctx, bson.M{
+		"_id": channel.ActiveEmoteSetID,
+	}).Decode(set); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return channel.EmoteIDs, nil
+		}
+		return nil, err
+	}
+
+	return set.EmoteIDs, nil
+}
+
+// GetActiveEmoteIDsMany: Get the emotes of the active sets of several channels, mapped by channel ID, with one query
+func (*emoteSets) GetActiveEmoteIDsMany(ctx context.Context, channels []*datastructure.User) (map[primitive.ObjectID][]primitive.ObjectID, error) {
+	result := make(map[primitive.ObjectID][]primitive.ObjectID, len(channels))
+	setIDs := []primitive.ObjectID{}
+	for _, channel := range channels {
+		result[channel.ID] = channel.EmoteIDs
+		if channel.ActiveEmoteSetID != nil {
+			setIDs = append(setIDs, *channel.ActiveEmoteSetID)
+		}
+	}
+	if len(setIDs) == 0 {
+		return result, nil
+	}
+
+	sets := []*datastructure.EmoteSet{}
+	cur, err := mongo.Collection(mongo.CollectionNameEmoteSets).Find(ctx, bson.M{
+		"_id": bson.M{"$in": setIDs},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &sets); err != nil {
+		return nil, err
+	}
+
+	setMap := make(map[primitive.ObjectID]*datastructure.EmoteSet, len(sets))
+	for _, set := range sets {
+		setMap[set.ID] = set
+	}
+	for _, channel := range channels {
+		if channel.ActiveEmoteSetID == nil {
+			continue
+		}
+		if set, ok := setMap[*channel.ActiveEmoteSetID]; ok {
+			result[channel.ID] = set.EmoteIDs
+		}
+	}
+
+	return result, nil
+}
+
+// Get: Get an emote set by its ID
+func (*emoteSets) Get(ctx context.Context, id primitive.ObjectID) (*datastructure.EmoteSet, error) {
+	set := &datastructure.EmoteSet{}
+	if err := mongo.Collection(mongo.CollectionNameEmoteSets).FindOne(ctx, bson.M{
+		"_id": id,
+	}).Decode(set); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// GetByOwner: Get all emote sets owned by a channel
+func (*emoteSets) GetByOwner(ctx context.Context, ownerID primitive.ObjectID) ([]*datastructure.EmoteSet, error) {
+	sets := []*datastructure.EmoteSet{}
+	cur, err := mongo.Collection(mongo.CollectionNameEmoteSets).Find(ctx, bson.M{
+		"owner": ownerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &sets); err != nil {
+		return nil, err
+	}
+
+	return sets, nil
+}
+
+// Count: Count the emote sets owned by a channel
+func (*emoteSets) Count(ctx context.Context, ownerID primitive.ObjectID) (int64, error) {
+	return mongo.Collection(mongo.CollectionNameEmoteSets).CountDocuments(ctx, bson.M{
+		"owner": ownerID,
+	})
+}
+
+// Create: Create a new emote set for a channel
+func (es *emoteSets) Create(ctx context.Context, owner *datastructure.User, name string, emoteIDs []primitive.ObjectID) (*datastructure.EmoteSet, error) {
+	// Channels which never used sets get their current emotes saved as a set first
+	if _, err := es.EnsureActive(ctx, owner); err != nil {
+		return nil, err
+	}
+
+	return es.insert(ctx, owner.ID, name, emoteIDs)
+}
+
+// EnsureActive: Get a channel's active emote set, creating one from the channel's current emotes if it has none
+func (es *emoteSets) EnsureActive(ctx context.Context, channel *datastructure.User) (*datastructure.EmoteSet, error) {
+	if channel.ActiveEmoteSetID != nil {
+		set, err := es.Get(ctx, *channel.ActiveEmoteSetID)
+		if err != mongo.ErrNoDocuments {
+			return set, err
+		}
+	}
+
+	set, err := es.insert(ctx, channel.ID, "Default", channel.EmoteIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := mongo.Collection(mongo.CollectionNameUsers).UpdateOne(ctx, bson.M{
+		"_id": channel.ID,
+	}, bson.M{
+		"$set": bson.M{"active_emote_set": set.ID},
+	}); err != nil {
+		return nil, err
+	}
+	channel.ActiveEmoteSetID = &set.ID
+
+	return set, nil
+}
+
+func (*emoteSets) insert(ctx context.Context, ownerID primitive.ObjectID, name string, emoteIDs []primitive.ObjectID) (*datastructure.EmoteSet, error) {
+	if emoteIDs == nil {
+		emoteIDs = []primitive.ObjectID{}
+	}
+
+	set := &datastructure.EmoteSet{
+		OwnerID:   ownerID,
+		Name:      name,
+		EmoteIDs:  emoteIDs,
+		CreatedAt: time.Now(),
+	}
+	res, err := mongo.Collection(mongo.CollectionNameEmoteSets).InsertOne(ctx, set)
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, fmt.Errorf("bad resp from mongo")
+	}
+	set.ID = id
+
+	return set, nil
+}
+
+// Rename: Change the name of an emote set
+func (*emoteSets) Rename(ctx context.Context, set *datastructure.EmoteSet, name string) error {
+	if _, err := mongo.Collection(mongo.CollectionNameEmoteSets).UpdateOne(ctx, bson.M{
+		"_id": set.ID,
+	}, bson.M{
+		"$set": bson.M{"name": name},
+	}); err != nil {
+		return err
+	}
+
+	set.Name = name
+	return nil
+}
+
+// Activate: Switch a channel to an emote set. The set's emotes become the channel's emotes.
+// Returns the emotes which were added and removed by the switch
+func (es *emoteSets) Activate(ctx context.Context, channel *datastructure.User, set *datastructure.EmoteSet) (added []primitive.ObjectID, removed []primitive.ObjectID, err error) {
+	// Save the channel's current emotes before switching away from them
+	if _, err := es.EnsureActive(ctx, channel); err != nil {
+		return nil, nil, err
+	}
+
+	previous := map[primitive.ObjectID]bool{}
+	for _, id := range channel.EmoteIDs {
+		previous[id] = true
+	}
+	next := map[primitive.ObjectID]bool{}
+	for _, id := range set.EmoteIDs {
+		next[id] = true
+		if !previous[id] {
+			added = append(added, id)
+		}
+	}
+	for _, id := range channel.EmoteIDs {
+		if !next[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	if _, err := mongo.Collection(mongo.CollectionNameUsers).UpdateOne(ctx, bson.M{
+		"_id": channel.ID,
+	}, bson.M{
+		"$set": bson.M{
+			"emotes":           set.EmoteIDs,
+			"active_emote_set": set.ID,
+		},
+	}); err != nil {
+		return nil, nil, err
+	}
+	channel.EmoteIDs = set.EmoteIDs
+	channel.ActiveEmoteSetID = &set.ID
+
+	return added, removed, nil
+}
+
+// SyncActive: Write a channel's current emotes to its active emote set, after they were changed
+func (*emoteSets) SyncActive(ctx context.Context, channel *datastructure.User) error {
+	if channel.ActiveEmoteSetID == nil {
+		return nil
+	}
+
+	_, err := mongo.Collection(mongo.CollectionNameEmoteSets).UpdateOne(ctx, bson.M{
+		"_id": channel.ActiveEmoteSetID,
+	}, bson.M{
+		"$set": bson.M{"emotes": channel.EmoteIDs},
+	})
+	return err
+}
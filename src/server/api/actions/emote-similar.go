@@ -0,0 +1,360 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/storage"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The maximum perceptual hash distance for emotes to be considered similar, from the "emote_dedup.max_distance" config option.
+// Capped to the distance at which similar emotes are guaranteed to share a hash segment
+func SimilarEmoteMaxDistance() int {
+	d := configure.Config.GetInt("emote_dedup.max_distance")
+	if d <= 0 || d > datastructure.PHashBandCount-1 {
+		d = datastructure.PHashBandCount - 1
+	}
+
+	return d
+}
+
+// The maximum amount of emotes sharing a hash segment with an upload which are compared to it
+const similarEmoteMaxCandidates = 1000
+
+// The maximum amount of emotes sharing a hash segment which are compared to each other when looking for duplicates.
+// Segments shared by more emotes than this are usually of plain images, such as blank frames
+const duplicateEmoteMaxBucketSize = 200
+
+type SimilarEmote struct {
+	Emote    *datastructure.Emote
+	Distance int
+}
+
+// GetPHash: Get the perceptual hashes of an emote, read from the database as they don't survive the JSON of the cache.
+// Returns mongo.ErrNoDocuments if the emote does not exist
+func (*emotes) GetPHash(ctx context.Context, id primitive.ObjectID) ([]int64, error) {
+	emote := &datastructure.Emote{}
+	if err := mongo.Collection(mongo.CollectionNameEmotes).FindOne(ctx, bson.M{
+		"_id": id,
+	}, options.FindOne().SetProjection(bson.M{"phash": 1})).Decode(emote); err != nil {
+		return nil, err
+	}
+
+	return emote.PHash, nil
+}
+
+// FindSimilarEmotes: Find live emotes whose perceptual hashes are within maxDistance of the specified hashes, closest first
+func (*emotes) FindSimilarEmotes(ctx context.Context, hashes []int64, maxDistance int, limit int, exclude *primitive.ObjectID) ([]SimilarEmote, error) {
+	bands := datastructure.EmoteUtil.PHashBands(hashes)
+	if len(bands) == 0 {
+		return []SimilarEmote{}, nil
+	}
+
+	filter := bson.M{
+		"status":      datastructure.EmoteStatusLive,
+		"phash_bands": bson.M{"$in": bands},
+	}
+	if exclude != nil {
+		filter["_id"] = bson.M{"$ne": exclude}
+	}
+
+	// Only the hashes are needed to compare the candidates
+	candidates := []*datastructure.Emote{}
+	cur, err := mongo.Collection(mongo.CollectionNameEmotes).Find(ctx, filter, options.Find().
+		SetProjection(bson.M{"phash": 1}).
+		SetLimit(similarEmoteMaxCandidates),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	result := []SimilarEmote{}
+	for _, e := range candidates {
+		d := datastructure.EmoteUtil.PHashDistance(hashes, e.PHash)
+		if d < 0 || d > maxDistance {
+			continue
+		}
+
+		result = append(result, SimilarEmote{e, d})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Distance < result[j].Distance
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	// Fetch the emotes which matched
+	ids := make([]primitive.ObjectID, len(result))
+	for i, r := range result {
+		ids[i] = r.Emote.ID
+	}
+	emotes := []*datastructure.Emote{}
+	cur, err = mongo.Collection(mongo.CollectionNameEmotes).Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &emotes); err != nil {
+		return nil, err
+	}
+	emoteMap := make(map[primitive.ObjectID]*datastructure.Emote, len(emotes))
+	for _, e := range emotes {
+		emoteMap[e.ID] = e
+	}
+
+	found := make([]SimilarEmote, 0, len(result))
+	for _, r := range result {
+		if e, ok := emoteMap[r.Emote.ID]; ok {
+			found = append(found, SimilarEmote{e, r.Distance})
+		}
+	}
+
+	return found, nil
+}
+
+// FindDuplicateEmotes: Find groups of live emotes which are similar to each other, largest groups first.
+// Used by moderators to find candidates for merging
+func (*emotes) FindDuplicateEmotes(ctx context.Context, maxDistance int, limit int) ([][]*datastructure.Emote, error) {
+	// List emotes by hash segment, which is read a segment at a time rather than grouped in the database,
+	// as the emotes sharing a segment could exceed the maximum size of a document
+	cur, err := mongo.Collection(mongo.CollectionNameEmotes).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"status":      datastructure.EmoteStatusLive,
+			"phash_bands": bson.M{"$exists": true},
+		}}},
+		{{Key: "$project", Value: bson.M{"phash": 1, "phash_bands": 1}}},
+		{{Key: "$unwind", Value: "$phash_bands"}},
+		{{Key: "$sort", Value: bson.M{"phash_bands": 1}}},
+	}, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	// Join emotes within the maximum distance of each other into groups
+	parent := map[primitive.ObjectID]primitive.ObjectID{}
+	var find func(id primitive.ObjectID) primitive.ObjectID
+	find = func(id primitive.ObjectID) primitive.ObjectID {
+		if p, ok := parent[id]; ok && p != id {
+			parent[id] = find(p)
+			return parent[id]
+		}
+		parent[id] = id
+		return id
+	}
+	join := func(bucket []*datastructure.Emote) {
+		for i, a := range bucket {
+			for _, e := range bucket[i+1:] {
+				if d := datastructure.EmoteUtil.PHashDistance(a.PHash, e.PHash); d < 0 || d > maxDistance {
+					continue
+				}
+
+				parent[find(a.ID)] = find(e.ID)
+			}
+		}
+	}
+
+	bucket := []*datastructure.Emote{}
+	var band int32
+	for cur.Next(ctx) {
+		doc := struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			PHash []int64            `bson:"phash"`
+			Band  int32              `bson:"phash_bands"`
+		}{}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+
+		if len(bucket) > 0 && doc.Band != band {
+			join(bucket)
+			bucket = bucket[:0]
+		}
+		band = doc.Band
+		if len(bucket) < duplicateEmoteMaxBucketSize {
+			bucket = append(bucket, &datastructure.Emote{ID: doc.ID, PHash: doc.PHash})
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	join(bucket)
+
+	groupMap := map[primitive.ObjectID][]primitive.ObjectID{}
+	for id := range parent {
+		root := find(id)
+		groupMap[root] = append(groupMap[root], id)
+	}
+
+	groups := [][]primitive.ObjectID{}
+	for _, g := range groupMap {
+		if len(g) > 1 {
+			sort.Slice(g, func(i, j int) bool { return g[i].Hex() < g[j].Hex() })
+			groups = append(groups, g)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i]) != len(groups[j]) {
+			return len(groups[i]) > len(groups[j])
+		}
+		return groups[i][0].Hex() < groups[j][0].Hex()
+	})
+	if limit > 0 && len(groups) > limit {
+		groups = groups[:limit]
+	}
+
+	// Fetch the grouped emotes
+	ids := []primitive.ObjectID{}
+	for _, g := range groups {
+		ids = append(ids, g...)
+	}
+	emotes := []*datastructure.Emote{}
+	if len(ids) > 0 {
+		cur, err = mongo.Collection(mongo.CollectionNameEmotes).Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return nil, err
+		}
+		if err := cur.All(ctx, &emotes); err != nil {
+			return nil, err
+		}
+	}
+	emoteMap := map[primitive.ObjectID]*datastructure.Emote{}
+	for _, e := range emotes {
+		emoteMap[e.ID] = e
+	}
+
+	result := make([][]*datastructure.Emote, 0, len(groups))
+	for _, g := range groups {
+		group := []*datastructure.Emote{}
+		for _, id := range g {
+			if e, ok := emoteMap[id]; ok {
+				group = append(group, e)
+			}
+		}
+		if len(group) > 1 {
+			result = append(result, group)
+		}
+	}
+
+	return result, nil
+}
+
+// DedupUpload: Hash an upload being processed and look for live emotes which look the same, as set by the
+// "emote_dedup.mode" config option. Rejected uploads return an error, failing the emote
+func (e *emotes) DedupUpload(ctx context.Context, job EmoteProcessingJob, readPath string) error {
+	mode := configure.Config.GetString("emote_dedup.mode")
+	if mode == "off" {
+		return nil
+	}
+
+	phash, err := datastructure.EmoteUtil.ComputePHash(readPath)
+	if err != nil {
+		return fmt.Errorf("Input File Not Readable: %s", err)
+	}
+
+	matches, err := e.FindSimilarEmotes(ctx, phash, SimilarEmoteMaxDistance(), 5, &job.EmoteID)
+	if err != nil {
+		return err
+	}
+	similar := make([]primitive.ObjectID, len(matches))
+	for i, m := range matches {
+		similar[i] = m.Emote.ID
+	}
+
+	// Users allowed to edit all emotes are never rejected
+	if len(similar) > 0 && mode == "reject" {
+		var actor datastructure.User
+		if err := mongo.Collection(mongo.CollectionNameUsers).FindOne(ctx, bson.M{"_id": job.ActorID}).Decode(&actor); err != nil {
+			return err
+		}
+		if !actor.HasPermission(datastructure.RolePermissionEmoteEditAll) {
+			return fmt.Errorf("A Similar Emote Already Exists (%s)", similar[0].Hex())
+		}
+	}
+
+	set := bson.M{
+		"phash":       phash,
+		"phash_bands": datastructure.EmoteUtil.PHashBands(phash),
+	}
+	if len(similar) > 0 {
+		set["similar"] = similar
+	}
+	_, err = mongo.Collection(mongo.CollectionNameEmotes).UpdateOne(ctx, bson.M{"_id": job.EmoteID}, bson.M{"$set": set})
+	return err
+}
+
+// BackfillPHashes: Hash up to limit live emotes which were never hashed, such as emotes created before duplicate detection,
+// from their largest file on the CDN, continuing after an emote ID. Returns the amount of emotes hashed, and the ID to continue
+// after, nil once there are no emotes left. Emotes whose file could not be downloaded are left for a later run
+func (*emotes) BackfillPHashes(ctx context.Context, after primitive.ObjectID, limit int64) (*primitive.ObjectID, int, error) {
+	cur, err := mongo.Collection(mongo.CollectionNameEmotes).Find(ctx, bson.M{
+		"_id":         bson.M{"$gt": after},
+		"status":      datastructure.EmoteStatusLive,
+		"phash_bands": bson.M{"$exists": false},
+	}, options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.M{"_id": 1}).SetLimit(limit))
+	if err != nil {
+		return nil, 0, err
+	}
+	emotes := []*datastructure.Emote{}
+	if err := cur.All(ctx, &emotes); err != nil {
+		return nil, 0, err
+	}
+
+	dir := fmt.Sprintf("%s/phash-backfill", configure.Config.GetString("temp_file_store"))
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	hashed := 0
+	for _, e := range emotes {
+		if ctx.Err() != nil {
+			return nil, hashed, ctx.Err()
+		}
+
+		b, err := storage.DownloadFile(configure.Config.GetString("aws_cdn_bucket"), fmt.Sprintf("emote/%s/4x", e.ID.Hex()))
+		if err != nil {
+			log.WithError(err).WithField("emote", e.ID.Hex()).Warn("BackfillPHashes, could not download emote")
+			continue
+		}
+		path := fmt.Sprintf("%s/%s", dir, e.ID.Hex())
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			return nil, hashed, err
+		}
+
+		// Emotes which can't be hashed get no segments, so that they aren't tried again
+		set := bson.M{"phash_bands": []int32{}}
+		if phash, err := datastructure.EmoteUtil.ComputePHash(path); err != nil {
+			log.WithError(err).WithField("emote", e.ID.Hex()).Warn("BackfillPHashes, could not hash emote")
+		} else {
+			set = bson.M{"phash": phash, "phash_bands": datastructure.EmoteUtil.PHashBands(phash)}
+		}
+		_ = os.Remove(path)
+
+		if _, err := mongo.Collection(mongo.CollectionNameEmotes).UpdateOne(ctx, bson.M{"_id": e.ID}, bson.M{"$set": set}); err != nil {
+			return nil, hashed, err
+		}
+		hashed++
+	}
+
+	if int64(len(emotes)) < limit {
+		return nil, hashed, nil
+	}
+	return &emotes[len(emotes)-1].ID, hashed, nil
+}
@@ -0,0 +1,159 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/cache"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The redis channel on which role changes are broadcast to all instances
+const RolesUpdateChannel = "roles:updated"
+
+// Fetch: Get all roles available and cache them in memory
+func (*roles) Fetch(ctx context.Context) ([]datastructure.Role, error) {
+	roles := []datastructure.Role{}
+	cur, err := mongo.Collection(mongo.CollectionNameRoles).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+
+	roles = append(roles, *datastructure.DefaultRole) // Add default role
+	if err := cur.All(ctx, &roles); err != nil {      // Fetch roles
+		return nil, err
+	}
+
+	cache.SetRoles(roles)
+	return roles, nil
+}
+
+// Listen: Refresh the cached roles whenever an instance changes a role, until the context is cancelled
+func (r *roles) Listen(ctx context.Context) {
+	ch := make(chan []byte, 1)
+	redis.Subscribe(ctx, ch, RolesUpdateChannel)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-ch:
+			payload := redis.PubSubPayloadRoles{}
+			if err := json.Unmarshal(msg, &payload); err != nil {
+				log.WithError(err).Error("roles, bad update payload")
+			}
+
+			roles, err := r.Fetch(ctx)
+			if err != nil {
+				log.WithError(err).Error("roles, could not refresh")
+				continue
+			}
+			log.WithFields(log.Fields{
+				"action":  payload.Action,
+				"role_id": payload.RoleID,
+				"count":   len(roles),
+			}).Info("roles refreshed")
+		}
+	}
+}
+
+// Create: Create a new role
+func (r *roles) Create(ctx context.Context, role *datastructure.Role) error {
+	role.ID = primitive.NewObjectID()
+	if _, err := mongo.Collection(mongo.CollectionNameRoles).InsertOne(ctx, role); err != nil {
+		return err
+	}
+
+	return r.publish(ctx, "CREATE", role.ID)
+}
+
+// Edit: Apply an update to a role
+func (r *roles) Edit(ctx context.Context, role *datastructure.Role, update bson.M) error {
+	after := options.After
+	if err := mongo.Collection(mongo.CollectionNameRoles).FindOneAndUpdate(ctx, bson.M{
+		"_id": role.ID,
+	}, update, &options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}).Decode(role); err != nil {
+		return err
+	}
+
+	return r.publish(ctx, "UPDATE", role.ID)
+}
+
+// Delete: Delete a role. Users with the role fall back to the default role
+func (r *roles) Delete(ctx context.Context, role *datastructure.Role) error {
+	if _, err := mongo.Collection(mongo.CollectionNameRoles).DeleteOne(ctx, bson.M{
+		"_id": role.ID,
+	}); err != nil {
+		return err
+	}
+
+	if _, err := mongo.Collection(mongo.CollectionNameUsers).UpdateMany(ctx, bson.M{
+		"role": role.ID,
+	}, bson.M{
+		"$set":   bson.M{"role": nil},
+		"$unset": bson.M{"role_position": 1},
+	}); err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return r.publish(ctx, "DELETE", role.ID)
+}
+
+// CanGrant: Get whether an actor holds every permission in a set of bits, such as the allowed & denied bits changed on a role.
+// Returns the first permission the actor lacks
+func (*roles) CanGrant(actor *datastructure.User, bits int64) (bool, int64) {
+	for flag := int64(1); flag <= datastructure.RolePermissionAll; flag <<= 1 {
+		if bits&flag == 0 {
+			continue
+		}
+		if !actor.HasPermission(flag) {
+			return false, flag
+		}
+	}
+
+	return true, 0
+}
+
+// SyncPositions: Copy the position of each role to the users who have it, which lists of users are sorted by
+func (*roles) SyncPositions(ctx context.Context) error {
+	roles, _ := cache.GetRoles().([]datastructure.Role)
+	for _, role := range roles {
+		if role.Default {
+			continue
+		}
+
+		if _, err := mongo.Collection(mongo.CollectionNameUsers).UpdateMany(ctx, bson.M{
+			"role":          role.ID,
+			"role_position": bson.M{"$ne": role.Position},
+		}, bson.M{
+			"$set": bson.M{"role_position": role.Position},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Refresh this instance's cache then notify the other instances
+func (r *roles) publish(ctx context.Context, action string, id primitive.ObjectID) error {
+	if _, err := r.Fetch(ctx); err != nil {
+		return fmt.Errorf("could not refresh roles: %v", err)
+	}
+	if err := r.SyncPositions(ctx); err != nil {
+		log.WithError(err).Error("roles, could not sync positions")
+	}
+
+	return redis.Publish(ctx, RolesUpdateChannel, redis.PubSubPayloadRoles{
+		Action: action,
+		RoleID: id.Hex(),
+	})
+}
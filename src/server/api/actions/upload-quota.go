@@ -0,0 +1,159 @@
+package actions
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The window over which daily uploads are counted
+const UploadQuotaWindow = time.Hour * 24
+
+// UploadQuota: How many emotes a user has created in the past day and owns, and how many they may.
+// A limit of -1 means there is no limit
+type UploadQuota struct {
+	DailyLimit int32
+	DailyUsed  int32
+	// When the oldest counted upload leaves the window, freeing up a daily upload. Zero if there are none
+	DailyResetAt time.Time
+
+	OwnedLimit int32
+	Owned      int32
+}
+
+// DailyRemaining: The amount of emotes which can still be created today, -1 if unlimited
+func (q *UploadQuota) DailyRemaining() int32 {
+	return remainingQuota(q.DailyLimit, q.DailyUsed)
+}
+
+// OwnedRemaining: The amount of emotes which can still be created before the owned limit is reached, -1 if unlimited
+func (q *UploadQuota) OwnedRemaining() int32 {
+	return remainingQuota(q.OwnedLimit, q.Owned)
+}
+
+func remainingQuota(limit int32, used int32) int32 {
+	if limit < 0 {
+		return -1
+	}
+	if used >= limit {
+		return 0
+	}
+
+	return limit - used
+}
+
+// GetUploadQuota: Get the upload quota of a user, whose role must be resolved.
+// Emotes count against their owner whoever uploaded them, and deleted emotes still count towards the daily quota
+func (*emotes) GetUploadQuota(ctx context.Context, user *datastructure.User) (*UploadQuota, error) {
+	subscriptionIDs, err := getSubscriptionIDs(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleID := ""
+	if user.Role != nil {
+		roleID = user.Role.ID.Hex()
+	}
+	quota := &UploadQuota{
+		DailyLimit: getUploadQuotaLimit("daily", 25, roleID, subscriptionIDs),
+		OwnedLimit: getUploadQuotaLimit("owned", 1000, roleID, subscriptionIDs),
+	}
+
+	col := mongo.Collection(mongo.CollectionNameEmotes)
+	owned, err := col.CountDocuments(ctx, bson.M{
+		"owner":  user.ID,
+		"status": bson.M{"$nin": bson.A{datastructure.EmoteStatusDeleted, datastructure.EmoteStatusFailed}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	quota.Owned = int32(owned)
+
+	// Emotes' IDs are the time they were created at
+	since := primitive.NewObjectIDFromTimestamp(time.Now().Add(-UploadQuotaWindow))
+	created := []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}{}
+	cur, err := col.Find(ctx, bson.M{
+		"owner": user.ID,
+		"_id":   bson.M{"$gt": since},
+	}, options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &created); err != nil {
+		return nil, err
+	}
+	quota.DailyUsed = int32(len(created))
+
+	// Once the limit is reached, an upload is freed up when enough of the oldest uploads have left the window
+	if n := len(created); n > 0 {
+		i := 0
+		if quota.DailyLimit >= 0 && quota.DailyUsed >= quota.DailyLimit {
+			i = n - int(quota.DailyLimit)
+			if i >= n {
+				i = n - 1
+			}
+		}
+		quota.DailyResetAt = created[i].ID.Timestamp().Add(UploadQuotaWindow)
+	}
+
+	return quota, nil
+}
+
+// Get a limit of the upload quota, the default raised by the user's role or subscriptions. -1 means there is no limit
+func getUploadQuotaLimit(name string, def int32, roleID string, subscriptionIDs []string) int32 {
+	limit := def
+	if l := configure.Config.GetInt32("limits.upload_quota." + name); l != 0 {
+		limit = l
+	}
+
+	keys := make([]string, 0, len(subscriptionIDs)+1)
+	if roleID != "" {
+		keys = append(keys, "limits.upload_quota.roles."+roleID+"."+name)
+	}
+	for _, id := range subscriptionIDs {
+		keys = append(keys, "limits.upload_quota.subscriptions."+id+"."+name)
+	}
+	for _, key := range keys {
+		if limit < 0 {
+			break
+		}
+		if !configure.Config.IsSet(key) {
+			continue
+		}
+		if l := configure.Config.GetInt32(key); l < 0 || l > limit {
+			limit = l
+		}
+	}
+
+	return limit
+}
+
+// Get the IDs of the subscriptions a user is entitled to
+func getSubscriptionIDs(ctx context.Context, userID primitive.ObjectID) ([]string, error) {
+	entitlements := []datastructure.Entitlement{}
+	cur, err := mongo.Collection(mongo.CollectionNameEntitlements).Find(ctx, bson.M{
+		"user_id":  userID,
+		"kind":     datastructure.EntitlementKindSubscription,
+		"disabled": bson.M{"$not": bson.M{"$eq": true}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &entitlements); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(entitlements))
+	for i, e := range entitlements {
+		ids[i] = Entitlements.With(ctx, e).ReadSubscriptionData().ObjectReference.Hex()
+	}
+	return ids, nil
+}
@@ -3,11 +3,9 @@ package actions
 import (
 	"context"
 	"fmt"
-	"sort"
 
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
-	"github.com/SevenTV/ServerGo/src/utils"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -67,31 +65,70 @@ func (users) Get(ctx context.Context, q bson.M) (*UserBuilder, error) {
 // GetRole: Returns the user's current role
 func (b UserBuilder) GetRole() datastructure.Role {
 	ents, _ := b.FetchEntitlements(&datastructure.EntitlementKindRole)
+	entitled := make([]primitive.ObjectID, len(ents))
 	for i, ent := range ents {
-		ents[i] = ent
+		entitled[i] = ent.ReadRoleData().ObjectReference
 	}
 
-	// Sort the entitlements by role position
-	sort.Slice(ents, func(i, j int) bool {
-		roleID := ents[i].ReadRoleData().ObjectReference
-		role1 := datastructure.GetRole(&roleID)
-		role2 := datastructure.GetRole(b.User.RoleID)
+	return highestRole(b.User.RoleID, entitled)
+}
+
+// GetManyWithRoles: fetch several users via a query, resolving the roles of all of them with one query for their entitlements
+func (users) GetManyWithRoles(ctx context.Context, q bson.M) ([]*datastructure.User, error) {
+	users := []*datastructure.User{}
+	cur, err := mongo.Collection(mongo.CollectionNameUsers).Find(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return users, nil
+	}
 
-		return role1.Position > role2.Position
+	userIDs := make([]primitive.ObjectID, len(users))
+	for i, u := range users {
+		userIDs[i] = u.ID
+	}
+	entitlements := []datastructure.Entitlement{}
+	cur, err = mongo.Collection(mongo.CollectionNameEntitlements).Find(ctx, bson.M{
+		"user_id":  bson.M{"$in": userIDs},
+		"kind":     datastructure.EntitlementKindRole,
+		"disabled": bson.M{"$not": bson.M{"$eq": true}},
 	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &entitlements); err != nil {
+		return nil, err
+	}
 
-	// The role assigned to the user directly via their user rather than an Entitlement
-	hardRole := datastructure.GetRole(b.User.RoleID)
+	entitled := map[primitive.ObjectID][]primitive.ObjectID{}
+	for _, e := range entitlements {
+		data := Entitlements.With(ctx, e).ReadRoleData()
+		entitled[e.UserID] = append(entitled[e.UserID], data.ObjectReference)
+	}
+	for _, u := range users {
+		role := highestRole(u.RoleID, entitled[u.ID])
+		u.Role = &role
+		u.RoleID = &role.ID
+	}
 
-	if len(ents) > 0 {
-		refID := ents[0].ReadRoleData().ObjectReference
-		refRole := datastructure.GetRole(&refID)
+	return users, nil
+}
 
-		// If the hard role has the same or higher position, it will be returned instead of the entitled role
-		return utils.Ternary(hardRole.Position >= refRole.Position, hardRole, refRole).(datastructure.Role)
-	} else {
-		return hardRole
+// Get the role of a user from the one assigned to them directly and the ones they are entitled to.
+// The directly assigned role wins over entitled roles at the same position
+func highestRole(hardRoleID *primitive.ObjectID, entitled []primitive.ObjectID) datastructure.Role {
+	role := datastructure.GetRole(hardRoleID)
+	for i := range entitled {
+		if r := datastructure.GetRole(&entitled[i]); r.Position > role.Position {
+			role = r
+		}
 	}
+
+	return role
 }
 
 // AssignEntitlements: adds entitlements to the user object
@@ -0,0 +1,71 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/metrics"
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The amount of emotes hashed between refreshes of the lock
+const emoteHashBackfillBatch = 100
+
+// Compute the perceptual hashes of the live emotes which have none, such as emotes created before duplicate detection,
+// so that uploads are compared against the whole catalogue. Runs on whichever pod holds the lock
+func BackfillEmoteHashes(ctx context.Context) {
+	log.Info("Task=BackfillEmoteHashes, starting now")
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if configure.Config.GetString("emote_dedup.mode") != "off" {
+			backfillEmoteHashes(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func backfillEmoteHashes(ctx context.Context) {
+	lock, err := redis.GetLocker().Obtain(ctx, "lock:task:backfill-emote-hashes", time.Minute*5, nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = lock.Release(context.Background())
+	}()
+
+	total := 0
+	var after primitive.ObjectID
+	for ctx.Err() == nil {
+		start := time.Now()
+		next, count, err := actions.Emotes.BackfillPHashes(ctx, after, emoteHashBackfillBatch)
+		metrics.ObserveTask("backfill-emote-hashes", start, err)
+		total += count
+		if err != nil {
+			log.WithError(err).Error("BackfillEmoteHashes")
+			return
+		}
+		if next == nil {
+			break
+		}
+		after = *next
+
+		if err := lock.Refresh(ctx, time.Minute*5, nil); err != nil {
+			log.WithError(err).Error("BackfillEmoteHashes, could not refresh lock")
+			return
+		}
+	}
+
+	if total > 0 {
+		log.WithField("count", total).Info("BackfillEmoteHashes, hashed emotes")
+	}
+}
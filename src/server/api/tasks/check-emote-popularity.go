@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/SevenTV/ServerGo/src/discord"
+	"github.com/SevenTV/ServerGo/src/metrics"
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/redis"
 	"github.com/bsm/redislock"
@@ -18,7 +19,7 @@ import (
 func CheckEmotesPopularity(ctx context.Context) error {
 	// Acquire lock. We won't allow any other pod to execute this concurrently
 	lockCtx := context.Background()
-	lock, err := redis.GetLocker().Obtain(lockCtx, "lock:task:check-emotes-popularity", time.Hour*6+time.Second*30, &redislock.Options{
+	lock, err := redis.GetLocker().Obtain(ctx, "lock:task:check-emotes-popularity", time.Hour*6+time.Second*30, &redislock.Options{
 		RetryStrategy: redislock.ExponentialBackoff(time.Second*5, time.Minute*10),
 	})
 	if err != nil {
@@ -35,7 +36,7 @@ func CheckEmotesPopularity(ctx context.Context) error {
 		wg := sync.WaitGroup{}
 		wg.Add(1)
 		defer wg.Done()
-		go discord.SendPopularityCheckUpdateNotice(&wg)
+		discord.Go(func() { discord.SendPopularityCheckUpdateNotice(&wg) })
 
 		// Create a pipeline for ranking emotes by channel count
 		popCheck := mongo.Pipeline{
@@ -115,7 +116,13 @@ func CheckEmotesPopularity(ctx context.Context) error {
 		return err
 	}
 
+	// Update cycles in progress, waited for before giving up the lock
+	running := sync.WaitGroup{}
+	loopDone := make(chan struct{})
+
 	defer func() {
+		<-loopDone // No more cycles are started once the loop is done
+		running.Wait()
 		log.Info("Task=CheckEmotesPopularity, giving up lock, another pod will take over.")
 		if err := lock.Release(lockCtx); err != nil {
 			log.WithError(err).Error("CheckEmotesPopularity, failed to release lock")
@@ -125,6 +132,7 @@ func CheckEmotesPopularity(ctx context.Context) error {
 	}()
 
 	go func() {
+		defer close(loopDone)
 		for {
 			select {
 			case <-ctx.Done():
@@ -136,8 +144,12 @@ func CheckEmotesPopularity(ctx context.Context) error {
 				}
 
 				// Run the check
+				running.Add(1)
 				go func() {
+					defer running.Done()
+					start := time.Now()
 					err := f()
+					metrics.ObserveTask("check-emotes-popularity", start, err)
 					if err != nil {
 						log.WithError(err).Error("CheckEmotesPopularity")
 						ticker.Stop()
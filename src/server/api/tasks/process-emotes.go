@@ -0,0 +1,212 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/bsm/redislock"
+	jsoniter "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// How long a worker holds the lock on a job without refreshing it.
+// Jobs whose lock expired are considered orphaned and requeued
+const emoteProcessingLockTTL = 30 * time.Second
+
+// The maximum amount of times an orphaned job is requeued before the emote is marked as failed
+const emoteProcessingMaxAttempts = 3
+
+// Process uploaded emotes from the queue.
+// Every pod runs workers, a job is claimed by moving it to the in-progress list and holding a lock on it
+func ProcessEmotes(ctx context.Context) {
+	workers := configure.Config.GetInt("emote_processing.workers")
+	if workers <= 0 {
+		workers = 2
+	}
+
+	log.WithField("workers", workers).Info("Task=ProcessEmotes, starting now")
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			emoteProcessingWorker(ctx)
+		}()
+	}
+
+	// Requeue jobs orphaned by a pod that died mid-processing
+	ticker := time.NewTicker(emoteProcessingLockTTL)
+	defer ticker.Stop()
+	unlocked := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			// Let the workers finish the jobs they are running
+			wg.Wait()
+			return
+		case <-ticker.C:
+			unlocked = requeueOrphanedEmotes(ctx, unlocked)
+		}
+	}
+}
+
+func emoteProcessingWorker(ctx context.Context) {
+	for {
+		raw, err := redis.Client.BRPopLPush(ctx, actions.EmoteProcessingQueueKey, actions.EmoteProcessingInProgressKey, 5*time.Second).Result()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == redis.ErrNil {
+			continue
+		} else if err != nil {
+			log.WithError(err).Error("ProcessEmotes, could not pop job")
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		job := actions.EmoteProcessingJob{}
+		if err := json.UnmarshalFromString(raw, &job); err != nil {
+			log.WithError(err).WithField("job", raw).Error("ProcessEmotes, bad job")
+			redis.Client.LRem(ctx, actions.EmoteProcessingInProgressKey, 1, raw)
+			continue
+		}
+
+		// Failing to obtain the lock means the job is being requeued or run by another worker.
+		// Unless it was already taken off the in-progress list, return it to the queue so it isn't stuck there
+		lock, err := redis.GetLocker().Obtain(ctx, jobLockKey(job), emoteProcessingLockTTL, nil)
+		if err != nil {
+			if removed, err := redis.Client.LRem(context.Background(), actions.EmoteProcessingInProgressKey, 1, raw).Result(); err != nil {
+				log.WithError(err).Error("ProcessEmotes, could not return job")
+			} else if removed > 0 {
+				if err := redis.Client.LPush(context.Background(), actions.EmoteProcessingQueueKey, raw).Err(); err != nil {
+					log.WithError(err).WithField("job", raw).Error("ProcessEmotes, could not return job")
+				}
+			}
+			continue
+		}
+
+		runEmoteProcessingJob(lock, job)
+
+		redis.Client.LRem(context.Background(), actions.EmoteProcessingInProgressKey, 1, raw)
+		if err := lock.Release(context.Background()); err != nil && err != redislock.ErrLockNotHeld {
+			log.WithError(err).Error("ProcessEmotes, failed to release lock")
+		}
+	}
+}
+
+func runEmoteProcessingJob(lock *redislock.Lock, job actions.EmoteProcessingJob) {
+	logInfo := log.WithField("emote", job.EmoteID.Hex())
+
+	// Keep the lock while the job is running
+	jobCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		ticker := time.NewTicker(emoteProcessingLockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				if err := lock.Refresh(jobCtx, emoteProcessingLockTTL, nil); err != nil {
+					logInfo.WithError(err).Error("ProcessEmotes, could not refresh lock")
+				}
+			}
+		}
+	}()
+
+	// Skip emotes which are no longer waiting to be processed, i.e if they were deleted meanwhile
+	if err := mongo.Collection(mongo.CollectionNameEmotes).FindOne(jobCtx, bson.M{
+		"_id":    job.EmoteID,
+		"status": datastructure.EmoteStatusProcessing,
+	}).Err(); err == mongo.ErrNoDocuments {
+		actions.Emotes.DeleteUpload(job)
+		return
+	} else if err != nil {
+		logInfo.WithError(err).Error("mongo")
+	}
+
+	if err := actions.Emotes.ProcessEmote(jobCtx, job); err != nil {
+		logInfo.WithError(err).Error("ProcessEmotes, processing failed")
+		if err := actions.Emotes.SetEmoteStatus(jobCtx, job.EmoteID, datastructure.EmoteStatusFailed, err.Error()); err != nil {
+			logInfo.WithError(err).Error("mongo")
+		}
+	}
+
+	actions.Emotes.DeleteUpload(job)
+}
+
+// Move jobs which are in progress but whose lock expired back to the queue.
+// A worker holds no lock between claiming a job and locking it, so a job is only orphaned once it was found
+// unlocked on two checks in a row. Returns the jobs found unlocked, to be given to the next check
+func requeueOrphanedEmotes(ctx context.Context, unlocked map[string]bool) map[string]bool {
+	stillUnlocked := map[string]bool{}
+	jobs, err := redis.Client.LRange(ctx, actions.EmoteProcessingInProgressKey, 0, -1).Result()
+	if err != nil {
+		log.WithError(err).Error("ProcessEmotes, could not list jobs in progress")
+		return stillUnlocked
+	}
+
+	for _, raw := range jobs {
+		job := actions.EmoteProcessingJob{}
+		if err := json.UnmarshalFromString(raw, &job); err != nil {
+			continue
+		}
+
+		// A held lock means a worker is still running the job
+		if held, err := redis.Client.Exists(ctx, jobLockKey(job)).Result(); err != nil || held > 0 {
+			continue
+		}
+		if !unlocked[raw] {
+			stillUnlocked[raw] = true
+			continue
+		}
+		lock, err := redis.GetLocker().Obtain(ctx, jobLockKey(job), emoteProcessingLockTTL, nil)
+		if err != nil {
+			continue
+		}
+
+		logInfo := log.WithField("emote", job.EmoteID.Hex()).WithField("attempts", job.Attempts)
+		removed, err := redis.Client.LRem(ctx, actions.EmoteProcessingInProgressKey, 1, raw).Result()
+
+		// Release before requeuing, so that a worker can claim the job right away
+		if err := lock.Release(ctx); err != nil {
+			logInfo.WithError(err).Error("ProcessEmotes, failed to release lock")
+		}
+		if err != nil || removed == 0 {
+			continue
+		}
+
+		job.Attempts++
+		if job.Attempts >= emoteProcessingMaxAttempts {
+			logInfo.Warn("ProcessEmotes, giving up on orphaned job")
+			if err := actions.Emotes.SetEmoteStatus(ctx, job.EmoteID, datastructure.EmoteStatusFailed, "Processing was interrupted too many times"); err != nil {
+				logInfo.WithError(err).Error("mongo")
+			}
+			actions.Emotes.DeleteUpload(job)
+			continue
+		}
+		if err := actions.Emotes.EnqueueProcessing(ctx, job); err != nil {
+			logInfo.WithError(err).Error("ProcessEmotes, could not requeue orphaned job")
+			continue
+		}
+
+		logInfo.Info("ProcessEmotes, requeued orphaned job")
+	}
+
+	return stillUnlocked
+}
+
+func jobLockKey(job actions.EmoteProcessingJob) string {
+	return fmt.Sprintf("lock:task:process-emote:%s", job.EmoteID.Hex())
+}
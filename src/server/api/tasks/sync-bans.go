@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/metrics"
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/bsm/redislock"
+	log "github.com/sirupsen/logrus"
+)
+
+// Lift expired bans and reconcile the bans held in memory with the database.
+// Every pod reconciles, expired bans are lifted by whichever pod holds the lock
+func SyncBans(ctx context.Context) {
+	interval := configure.Config.GetDuration("bans.sync_interval")
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	log.WithField("interval", interval).Info("Task=SyncBans, starting now")
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if lock, err := redis.GetLocker().Obtain(ctx, "lock:task:clear-expired-bans", interval, nil); err == nil {
+			count, err := actions.Bans.ClearExpired(ctx)
+			if err != nil {
+				log.WithError(err).Error("SyncBans, could not clear expired bans")
+			} else if count > 0 {
+				log.WithField("count", count).Info("SyncBans, lifted expired bans")
+			}
+			if err := lock.Release(context.Background()); err != nil && err != redislock.ErrLockNotHeld {
+				log.WithError(err).Error("SyncBans, failed to release lock")
+			}
+		}
+
+		start := time.Now()
+		err := actions.Bans.FetchBans(ctx)
+		if err != nil {
+			log.WithError(err).Error("SyncBans, could not reconcile bans")
+		}
+		metrics.ObserveTask("sync-bans", start, err)
+	}
+}
@@ -2,7 +2,7 @@ package tasks
 
 import (
 	"context"
-	"time"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -10,17 +10,50 @@ import (
 var taskCtx context.Context = context.Background()
 var taskCancelCtx context.CancelFunc
 
+// The running tasks, waited for on cleanup
+var taskWg = sync.WaitGroup{}
+
 func Start() {
 	ctx, cancel := context.WithCancel(taskCtx)
 	taskCtx = ctx
 	taskCancelCtx = cancel
 
-	if err := CheckEmotesPopularity(taskCtx); err != nil {
+	run(ProcessEmotes)
+	run(SyncBans)
+	run(BackfillEmoteHashes)
+
+	taskWg.Add(1)
+	defer taskWg.Done()
+	if err := CheckEmotesPopularity(taskCtx); err != nil && taskCtx.Err() == nil {
 		log.WithError(err).Error("failed to check popularity")
 	}
 }
 
-func Cleanup() {
-	taskCancelCtx()
-	time.Sleep(time.Millisecond * 500)
+// Run a task in the background, keeping track of it until it returns
+func run(task func(ctx context.Context)) {
+	taskWg.Add(1)
+	go func() {
+		defer taskWg.Done()
+		task(taskCtx)
+	}()
+}
+
+// Cleanup stops the tasks and waits for them to finish their current work and release their locks, until the context is done
+func Cleanup(ctx context.Context) error {
+	if taskCancelCtx != nil {
+		taskCancelCtx()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		taskWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/restutil"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+* Query Params:
+* emote: the ID of an emote being processed
+*
+* Sends the emote's current status, then each change until it is live or processing failed
+ */
+func EmoteStatusSSE(router fiber.Router) {
+	router.Get("/emote-status", func(c *fiber.Ctx) error {
+		id, err := primitive.ObjectIDFromHex(c.Query("emote"))
+		if err != nil {
+			return restutil.MalformedObjectId().Send(c)
+		}
+
+		// Subscribe before reading the current status so that no transition is missed
+		ctx, cancel := context.WithCancel(streamsCtx)
+		ch := make(chan []byte, 4)
+		redis.Subscribe(ctx, ch, fmt.Sprintf("events-v1:emote-status:%s", id.Hex()))
+
+		var emote datastructure.Emote
+		if err := mongo.Collection(mongo.CollectionNameEmotes).FindOne(c.Context(), bson.M{
+			"_id": id,
+		}).Decode(&emote); err != nil {
+			cancel()
+			if err == mongo.ErrNoDocuments {
+				return restutil.ErrUnknownEmote().Send(c)
+			}
+			return restutil.ErrInternalServer().Send(c, err.Error())
+		}
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer cancel()
+
+			interval := heartbeatInterval()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			// Stop once the emote is no longer being processed
+			if err := writeSSE(w, "", &Message{Action: ActionUpdate, Payload: redis.EventApiV1EmoteStatus{
+				EmoteID: emote.ID.Hex(),
+				Status:  emote.Status,
+				Message: emote.StatusMessage,
+			}}); err != nil || emote.Status != datastructure.EmoteStatusProcessing {
+				return
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := writeSSE(w, "", &Message{Action: ActionHeartbeat}); err != nil {
+						return
+					}
+				case b := <-ch:
+					ev := redis.EventApiV1EmoteStatus{}
+					if err := json.Unmarshal(b, &ev); err != nil {
+						log.WithError(err).Error("events, bad emote status event")
+						continue
+					}
+
+					if err := writeSSE(w, "", &Message{Action: ActionUpdate, Payload: ev}); err != nil || ev.Status != datastructure.EmoteStatusProcessing {
+						return
+					}
+				}
+			}
+		})
+
+		return nil
+	})
+}
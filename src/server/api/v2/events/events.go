@@ -0,0 +1,152 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/server/middleware"
+	"github.com/gofiber/fiber/v2"
+	jsoniter "github.com/json-iterator/go"
+	log "github.com/sirupsen/logrus"
+)
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+var channelLoginRegex = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+
+const (
+	ActionHello     = "hello"     // Sent once a connection is established
+	ActionHeartbeat = "heartbeat" // Sent periodically to keep the connection alive
+	ActionUpdate    = "update"    // Sent when a channel emote is added, edited or removed
+	ActionJoin      = "join"      // Subscribe to a channel
+	ActionPart      = "part"      // Unsubscribe from a channel
+	ActionError     = "error"     // Sent when a client message could not be handled
+)
+
+// Cancelled once the server shuts down, ending the open streams
+var streamsCtx, closeStreams = context.WithCancel(context.Background())
+
+// Close ends the open event streams and any opened afterwards, as they would otherwise keep the server from shutting
+// down. Clients reconnect to another instance
+func Close() {
+	closeStreams()
+}
+
+type Message struct {
+	Action  string      `json:"action"`
+	Payload interface{} `json:"payload,omitempty"`
+}
+
+type HelloPayload struct {
+	HeartbeatInterval int64 `json:"heartbeat_interval"` // The interval between heartbeats in milliseconds
+	MaxChannels       int   `json:"max_channels"`       // The maximum amount of channels a connection may join
+}
+
+type ErrorPayload struct {
+	Message string `json:"message"`
+}
+
+func Events(app fiber.Router) fiber.Router {
+	events := app.Group("/events")
+	if !configure.Config.GetBool("websocket.enabled") {
+		return events
+	}
+
+	events.Use(middleware.RateLimitMiddleware("events-connect", 30, 30*time.Second))
+
+	ChannelEmotesSSE(events)
+	ChannelEmotesWebSocket(events)
+	EmoteStatusSSE(events)
+
+	return events
+}
+
+// Get the interval between heartbeats sent to the client
+func heartbeatInterval() time.Duration {
+	if i := configure.Config.GetInt("websocket.heartbeat_interval"); i > 0 {
+		return time.Duration(i) * time.Millisecond
+	}
+
+	return 30 * time.Second
+}
+
+// Get the maximum amount of channels a single connection may subscribe to
+func maxChannels() int {
+	if i := configure.Config.GetInt("websocket.max_channels"); i > 0 {
+		return i
+	}
+
+	return 100
+}
+
+// A session holds the channel subscriptions of a single client connection
+type session struct {
+	ctx  context.Context
+	ch   chan []byte
+	subs map[string]context.CancelFunc
+	mtx  sync.Mutex
+}
+
+func newSession(ctx context.Context) *session {
+	return &session{
+		ctx:  ctx,
+		ch:   make(chan []byte, 64),
+		subs: map[string]context.CancelFunc{},
+	}
+}
+
+// Join subscribes the session to a channel's emote events
+func (s *session) Join(channel string) (string, error) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	if !channelLoginRegex.MatchString(channel) {
+		return "", fmt.Errorf("Invalid Channel")
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if _, ok := s.subs[channel]; ok {
+		return channel, nil
+	}
+	if len(s.subs) >= maxChannels() {
+		return "", fmt.Errorf("Channel Limit Reached (%d)", maxChannels())
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	redis.Subscribe(ctx, s.ch, fmt.Sprintf("events-v1:channel-emotes:%s", channel))
+	s.subs[channel] = cancel
+
+	return channel, nil
+}
+
+// Part unsubscribes the session from a channel's emote events
+func (s *session) Part(channel string) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if cancel, ok := s.subs[channel]; ok {
+		cancel()
+		delete(s.subs, channel)
+	}
+}
+
+// Replay returns the events a channel received after the specified event ID
+func (s *session) Replay(channel string, lastEventID string) []redis.EventApiV1ChannelEmotes {
+	if lastEventID == "" {
+		return nil
+	}
+
+	events, err := redis.GetChannelEmotesEvents(s.ctx, channel, lastEventID)
+	if err != nil {
+		log.WithError(err).WithField("channel", channel).Error("events, could not replay channel emotes events")
+		return nil
+	}
+
+	return events
+}
@@ -0,0 +1,118 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/restutil"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+/*
+* Query Params:
+* channel: comma-separated list of channel logins to subscribe to
+* last_event_id: the ID of the last event received, used to resume after a reconnect.
+* The standard Last-Event-ID header takes precedence
+ */
+func ChannelEmotesSSE(router fiber.Router) {
+	router.Get("/channel-emotes", func(c *fiber.Ctx) error {
+		channels := strings.Split(c.Query("channel"), ",")
+		if len(channels) == 0 || channels[0] == "" {
+			return restutil.ErrMissingQueryParams().Send(c, "channel")
+		}
+		if len(channels) > maxChannels() {
+			return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Too Many Channels (%d)", maxChannels()))
+		}
+
+		lastEventID := c.Get("Last-Event-ID", c.Query("last_event_id"))
+
+		ctx, cancel := context.WithCancel(streamsCtx)
+		s := newSession(ctx)
+		for i, ch := range channels {
+			name, err := s.Join(ch)
+			if err != nil {
+				cancel()
+				return restutil.ErrBadRequest().Send(c, err.Error())
+			}
+			channels[i] = name
+		}
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer cancel()
+
+			interval := heartbeatInterval()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			if err := writeSSE(w, "", &Message{Action: ActionHello, Payload: HelloPayload{
+				HeartbeatInterval: interval.Milliseconds(),
+				MaxChannels:       maxChannels(),
+			}}); err != nil {
+				return
+			}
+
+			// Send the events missed since the client's last connection
+			seen := map[string]bool{}
+			for _, ch := range channels {
+				for _, ev := range s.Replay(ch, lastEventID) {
+					seen[ev.EventID] = true
+					if err := writeSSE(w, ev.EventID, &Message{Action: ActionUpdate, Payload: ev}); err != nil {
+						return
+					}
+				}
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := writeSSE(w, "", &Message{Action: ActionHeartbeat}); err != nil {
+						return
+					}
+				case b := <-s.ch:
+					ev := redis.EventApiV1ChannelEmotes{}
+					if err := json.Unmarshal(b, &ev); err != nil {
+						log.WithError(err).Error("events, bad channel emotes event")
+						continue
+					}
+					if seen[ev.EventID] {
+						continue
+					}
+
+					if err := writeSSE(w, ev.EventID, &Message{Action: ActionUpdate, Payload: ev}); err != nil {
+						return
+					}
+				}
+			}
+		})
+
+		return nil
+	})
+}
+
+// Write a message to an event stream.
+// An error is returned once the client has disconnected
+func writeSSE(w *bufio.Writer, id string, msg *Message) error {
+	data, err := json.Marshal(msg.Payload)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Action, data)
+
+	return w.Flush()
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	log "github.com/sirupsen/logrus"
+)
+
+// A message sent by the client
+type clientMessage struct {
+	Action  string `json:"action"`
+	Payload struct {
+		Channel     string `json:"channel"`
+		LastEventID string `json:"last_event_id"` // Replay the channel's events after this ID
+	} `json:"payload"`
+}
+
+func ChannelEmotesWebSocket(router fiber.Router) {
+	router.Use("/ws", func(c *fiber.Ctx) error {
+		if !websocket.IsWebSocketUpgrade(c) {
+			return fiber.ErrUpgradeRequired
+		}
+
+		return c.Next()
+	})
+
+	router.Get("/ws", websocket.New(func(c *websocket.Conn) {
+		ctx, cancel := context.WithCancel(streamsCtx)
+		defer cancel()
+
+		s := newSession(ctx)
+		interval := heartbeatInterval()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// Read client messages until the connection closes
+		incoming := make(chan clientMessage)
+		go func() {
+			defer cancel()
+			for {
+				msg := clientMessage{}
+				if err := c.ReadJSON(&msg); err != nil {
+					return
+				}
+
+				select {
+				case incoming <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		if err := c.WriteJSON(&Message{Action: ActionHello, Payload: HelloPayload{
+			HeartbeatInterval: interval.Milliseconds(),
+			MaxChannels:       maxChannels(),
+		}}); err != nil {
+			return
+		}
+
+		seen := map[string]bool{}
+		var err error
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err = c.WriteJSON(&Message{Action: ActionHeartbeat})
+			case msg := <-incoming:
+				switch msg.Action {
+				case ActionJoin:
+					channel, joinErr := s.Join(msg.Payload.Channel)
+					if joinErr != nil {
+						err = c.WriteJSON(&Message{Action: ActionError, Payload: ErrorPayload{joinErr.Error()}})
+						break
+					}
+
+					err = c.WriteJSON(&Message{Action: ActionJoin, Payload: map[string]string{"channel": channel}})
+					for _, ev := range s.Replay(channel, msg.Payload.LastEventID) {
+						if err != nil {
+							break
+						}
+
+						seen[ev.EventID] = true
+						err = c.WriteJSON(&Message{Action: ActionUpdate, Payload: ev})
+					}
+				case ActionPart:
+					s.Part(msg.Payload.Channel)
+					err = c.WriteJSON(&Message{Action: ActionPart, Payload: map[string]string{"channel": msg.Payload.Channel}})
+				case ActionHeartbeat:
+					err = c.WriteJSON(&Message{Action: ActionHeartbeat})
+				default:
+					err = c.WriteJSON(&Message{Action: ActionError, Payload: ErrorPayload{"Unknown Action"}})
+				}
+			case b := <-s.ch:
+				ev := redis.EventApiV1ChannelEmotes{}
+				if jsonErr := json.Unmarshal(b, &ev); jsonErr != nil {
+					log.WithError(jsonErr).Error("events, bad channel emotes event")
+					continue
+				}
+				if seen[ev.EventID] {
+					continue
+				}
+
+				err = c.WriteJSON(&Message{Action: ActionUpdate, Payload: ev})
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}))
+}
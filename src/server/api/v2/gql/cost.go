@@ -0,0 +1,185 @@
+package gql
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	"github.com/SevenTV/ServerGo/src/utils"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/selection"
+	"github.com/graph-gophers/graphql-go/types"
+)
+
+// The size assumed for lists which aren't sized by an argument
+const defaultListSize = 20
+
+// Sizes assumed for lists which aren't sized by an argument and are typically larger than the default
+var listSizes = map[string]int64{
+	"Query.third_party_emotes": 150,
+	"User.emotes":              150,
+	"User.owned_emotes":        150,
+	"User.third_party_emotes":  150,
+	"EmoteSet.emotes":          150,
+	"ChannelEmotes.emotes":     150,
+}
+
+// The cost of an operation, and the budget it was held to
+type QueryCost struct {
+	Requested int64 `json:"requested"`
+	Budget    int64 `json:"budget"`
+}
+
+// Get the cost budget of the actor of a request. Roles can be given a larger budget than other users
+func getCostBudget(ctx context.Context) int64 {
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return configure.Config.GetInt64("limits.gql_cost.anonymous")
+	}
+
+	budget := configure.Config.GetInt64("limits.gql_cost.authenticated")
+	if usr.Role != nil {
+		if b := configure.Config.GetInt64("limits.gql_cost.roles." + usr.Role.ID.Hex()); b > budget {
+			budget = b
+		}
+	}
+
+	return budget
+}
+
+// Compute the cost of the operation being executed, and reject it if it is above the actor's budget.
+// Objects cost 1 each, so lists of objects cost as much as the amount of items they were asked for
+func checkCost(ctx context.Context, schema *types.Schema, cost *QueryCost) error {
+	var total int64
+	for _, f := range graphql.SelectedFieldsFromContext(ctx) {
+		// Root fields are looked up on either root type, as query and mutation fields don't share names
+		t := schema.EntryPoints["query"]
+		if getFieldDefinition(t, f.Name) == nil {
+			t = schema.EntryPoints["mutation"]
+		}
+
+		c := fieldCost(t, f, 0)
+		if c < 1 {
+			c = 1
+		}
+		total += c
+	}
+
+	cost.Requested = total
+	cost.Budget = getCostBudget(ctx)
+	if cost.Budget > 0 && total > cost.Budget {
+		return resolvers.ErrQueryTooComplex(total, cost.Budget)
+	}
+
+	return nil
+}
+
+// Compute the cost of a field and its selections.
+// A size passed down by a parent applies to the lists it paginates, such as the nodes of a connection
+func fieldCost(parent types.NamedType, f *selection.SelectedField, inheritedSize int64) int64 {
+	def := getFieldDefinition(parent, f.Name)
+	if def == nil {
+		return 0
+	}
+
+	size, sized := getListSize(f)
+	multiplier := int64(1)
+	t := def.Type
+	for {
+		if nn, ok := t.(*types.NonNull); ok {
+			t = nn.OfType
+			continue
+		}
+		if l, ok := t.(*types.List); ok {
+			switch {
+			case sized:
+				multiplier *= size
+				sized = false // Nested lists, such as groups of emotes, are of the default size
+			case inheritedSize > 0:
+				multiplier *= inheritedSize
+				inheritedSize = 0
+			default:
+				if s, ok := listSizes[parent.TypeName()+"."+f.Name]; ok {
+					multiplier *= s
+				} else {
+					multiplier *= defaultListSize
+				}
+			}
+			t = l.OfType
+			continue
+		}
+		break
+	}
+
+	named, ok := t.(types.NamedType)
+	if !ok {
+		return 0
+	}
+	switch named.(type) {
+	case *types.ObjectTypeDefinition, *types.InterfaceTypeDefinition, *types.Union:
+	default:
+		return 0 // Scalars and enums are resolved with their parent
+	}
+
+	// A sized field which isn't a list paginates its children
+	if !sized {
+		size = 0
+	}
+	var children int64
+	for _, child := range f.SelectedFields {
+		children += fieldCost(named, child, size)
+	}
+
+	return multiplier * (1 + children)
+}
+
+// Get the amount of items a field was asked for, from its arguments
+func getListSize(f *selection.SelectedField) (int64, bool) {
+	var size int64
+	sized := false
+	for _, name := range []string{"limit", "pageSize"} {
+		if v, ok := toInt64(f.Args[name]); ok && (!sized || v < size) {
+			size, sized = v, true
+		}
+	}
+	for _, name := range []string{"list", "channels"} {
+		if list, ok := f.Args[name].([]interface{}); ok {
+			size, sized = int64(len(list)), true
+		}
+	}
+	if sized && size < 1 {
+		size = 1
+	}
+
+	return size, sized
+}
+
+func getFieldDefinition(t types.NamedType, name string) *types.FieldDefinition {
+	if strings.HasPrefix(name, "__") {
+		return nil // Introspection
+	}
+
+	switch t := t.(type) {
+	case *types.ObjectTypeDefinition:
+		return t.Fields.Get(name)
+	case *types.InterfaceTypeDefinition:
+		return t.Fields.Get(name)
+	}
+	return nil
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int32:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}
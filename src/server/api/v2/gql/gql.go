@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/metrics"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/loaders"
 	mutation_resolvers "github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers/mutation"
 	query_resolvers "github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers/query"
 	"github.com/SevenTV/ServerGo/src/server/middleware"
@@ -13,6 +15,7 @@ import (
 	"github.com/gobuffalo/packr/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
 	jsoniter "github.com/json-iterator/go"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -23,6 +26,9 @@ type GQLRequest struct {
 	Query         string                 `json:"query"`
 	Variables     map[string]interface{} `json:"variables"`
 	OperationName string                 `json:"operation_name"`
+	Extensions    struct {
+		PersistedQuery *PersistedQueryExtension `json:"persistedQuery"`
+	} `json:"extensions"`
 }
 
 var Ctx = context.Background()
@@ -45,6 +51,16 @@ type Query struct {
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
+// The most fields of an operation resolved concurrently
+func getMaxParallelism() int {
+	n := configure.Config.GetInt("gql.max_parallelism")
+	if n <= 0 {
+		n = 10
+	}
+
+	return n
+}
+
 func GQL(app fiber.Router) fiber.Router {
 	gql := app.Group("/gql", middleware.UserAuthMiddleware(false))
 
@@ -56,18 +72,40 @@ func GQL(app fiber.Router) fiber.Router {
 		log.WithError(err).Fatal("gql failed")
 	}
 
-	schema := graphql.MustParseSchema(s, &RootResolver{
+	root := &RootResolver{
 		&query_resolvers.QueryResolver{},
 		&mutation_resolvers.MutationResolver{},
-	}, graphql.UseFieldResolvers())
+	}
+	var schema *graphql.Schema
+	// The root resolver is given the whole operation before any field is resolved, so it is where its cost is checked
+	schema = graphql.MustParseSchema(s, func(ctx context.Context) (*RootResolver, error) {
+		if cost, ok := ctx.Value(utils.QueryCostKey).(*QueryCost); ok {
+			if err := checkCost(ctx, schema.ASTSchema(), cost); err != nil {
+				return nil, err
+			}
+		}
 
-	rl := configure.Config.GetIntSlice("limits.route.gql")
-	gql.Use(middleware.RateLimitMiddleware("gql", int32(rl[0]), time.Millisecond*time.Duration(rl[1])))
+		return root, nil
+	}, graphql.UseFieldResolvers(), graphql.MaxParallelism(getMaxParallelism()))
+
+	loadRegisteredQueries()
+
+	gql.Use(middleware.RouteRateLimitMiddleware("gql"))
 	gql.Post("/", func(c *fiber.Ctx) error {
 		req := GQLRequest{}
 		if err := c.BodyParser(&req); err != nil {
 			return err
 		}
+		if qErr := resolvePersistedQuery(c.Context(), schema, &req); qErr != nil {
+			status := 400
+			switch qErr {
+			case errPersistedQueryNotFound:
+				status = 200 // Clients answer this by sending the query text along with its hash
+			case errOperationNotAllowed:
+				status = 403
+			}
+			return c.Status(status).JSON(&graphql.Response{Errors: []*errors.QueryError{qErr}})
+		}
 		if err != nil {
 			log.WithError(err).Error("gql")
 			return c.Status(400).JSON(fiber.Map{
@@ -89,7 +127,26 @@ func GQL(app fiber.Router) fiber.Router {
 
 		rCtx := context.WithValue(Ctx, utils.RequestCtxKey, c)
 		rCtx = context.WithValue(rCtx, utils.UserKey, c.Locals("user"))
+		rCtx = context.WithValue(rCtx, utils.AccessTokenKey, c.Locals("access_token"))
+		cost := &QueryCost{}
+		rCtx = context.WithValue(rCtx, utils.QueryCostKey, cost)
+		rCtx = context.WithValue(rCtx, utils.LoadersKey, loaders.New(rCtx))
+		start := time.Now()
 		result := schema.Exec(rCtx, req.Query, req.OperationName, req.Variables)
+		operation := operationLabel(req.OperationName)
+		gqlResult := "success"
+		if len(result.Errors) > 0 {
+			gqlResult = "error"
+		}
+		metrics.GqlOperationDuration.WithLabelValues(operation, gqlResult).Observe(time.Since(start).Seconds())
+
+		// Report the cost consumed by the operation
+		if cost.Budget > 0 {
+			if result.Extensions == nil {
+				result.Extensions = map[string]interface{}{}
+			}
+			result.Extensions["cost"] = cost
+		}
 
 		status := 200
 
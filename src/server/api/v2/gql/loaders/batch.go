@@ -0,0 +1,105 @@
+package loaders
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Fetch the documents of a batch of IDs, keyed by ID. IDs with no document are left out
+type fetchFunc func(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error)
+
+// batchLoader coalesces the IDs loaded within a short window into a single fetch,
+// and remembers the result of every ID for the lifetime of the request
+type batchLoader struct {
+	ctx      context.Context
+	fetch    fetchFunc
+	wait     time.Duration
+	maxBatch int
+
+	mtx     sync.Mutex
+	results map[primitive.ObjectID]*result
+	batch   *batch
+}
+
+type batch struct {
+	ids     []primitive.ObjectID
+	results []*result
+}
+
+type result struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+func newBatchLoader(ctx context.Context, wait time.Duration, maxBatch int, fetch fetchFunc) *batchLoader {
+	return &batchLoader{
+		ctx:      ctx,
+		fetch:    fetch,
+		wait:     wait,
+		maxBatch: maxBatch,
+		results:  map[primitive.ObjectID]*result{},
+	}
+}
+
+// Load the document of an ID, waiting for the batch it is part of.
+// Returns a nil value if there is no document with this ID
+func (l *batchLoader) Load(id primitive.ObjectID) (interface{}, error) {
+	var full *batch
+
+	l.mtx.Lock()
+	res, ok := l.results[id]
+	if !ok {
+		res = &result{done: make(chan struct{})}
+		l.results[id] = res
+
+		b := l.batch
+		if b == nil {
+			b = &batch{}
+			l.batch = b
+			time.AfterFunc(l.wait, func() { l.dispatch(b) })
+		}
+		b.ids = append(b.ids, id)
+		b.results = append(b.results, res)
+		if len(b.ids) >= l.maxBatch {
+			full = b
+		}
+	}
+	l.mtx.Unlock()
+
+	if full != nil {
+		l.dispatch(full)
+	}
+
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-l.ctx.Done():
+		return nil, l.ctx.Err()
+	}
+}
+
+// Fetch a batch, unless it was already dispatched for being full
+func (l *batchLoader) dispatch(b *batch) {
+	l.mtx.Lock()
+	if l.batch != b {
+		l.mtx.Unlock()
+		return
+	}
+	l.batch = nil
+	l.mtx.Unlock()
+
+	values, err := l.fetch(l.ctx, b.ids)
+	for i, id := range b.ids {
+		res := b.results[i]
+		if err != nil {
+			res.err = err
+		} else {
+			res.value = values[id]
+		}
+		close(res.done)
+	}
+}
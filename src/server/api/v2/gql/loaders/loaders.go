@@ -0,0 +1,168 @@
+package loaders
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/cache"
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	mongocache "github.com/SevenTV/ServerGo/src/mongo/cache"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Loaders batch the lookups made by the resolvers of a single GraphQL request
+type Loaders struct {
+	Users  *UserLoader
+	Emotes *EmoteLoader
+	Roles  *RoleLoader
+}
+
+// New creates the loaders of a request. They must not outlive the request's context
+func New(ctx context.Context) *Loaders {
+	wait, maxBatch := GetWait(), GetMaxBatch()
+
+	return &Loaders{
+		Users:  &UserLoader{newBatchLoader(ctx, wait, maxBatch, fetchUsers)},
+		Emotes: &EmoteLoader{newBatchLoader(ctx, wait, maxBatch, fetchEmotes)},
+		Roles:  &RoleLoader{newBatchLoader(ctx, wait, maxBatch, fetchRoles)},
+	}
+}
+
+// For gets the loaders of the request a context belongs to, if any
+func For(ctx context.Context) *Loaders {
+	l, _ := ctx.Value(utils.LoadersKey).(*Loaders)
+	return l
+}
+
+// GetWait: how long a loader waits for more IDs before fetching a batch
+func GetWait() time.Duration {
+	wait := configure.Config.GetDuration("dataloader.wait")
+	if wait <= 0 {
+		wait = 2 * time.Millisecond
+	}
+
+	return wait
+}
+
+// GetMaxBatch: the maximum amount of IDs fetched in a batch
+func GetMaxBatch() int {
+	maxBatch := configure.Config.GetInt("dataloader.max_batch")
+	if maxBatch <= 0 {
+		maxBatch = 100
+	}
+
+	return maxBatch
+}
+
+type UserLoader struct {
+	l *batchLoader
+}
+
+// Load a user by ID. Returns nil if the user does not exist
+func (x *UserLoader) Load(id primitive.ObjectID) (*datastructure.User, error) {
+	v, err := x.l.Load(id)
+	if v == nil || err != nil {
+		return nil, err
+	}
+
+	return v.(*datastructure.User), nil
+}
+
+func fetchUsers(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error) {
+	users := []*datastructure.User{}
+	if err := cache.Find(ctx, "users", "", bson.M{
+		"_id": bson.M{"$in": ids},
+	}, &users); err != nil {
+		return nil, err
+	}
+
+	result := make(map[primitive.ObjectID]interface{}, len(users))
+	for _, u := range users {
+		result[u.ID] = u
+	}
+	return result, nil
+}
+
+type EmoteLoader struct {
+	l *batchLoader
+}
+
+// Load an emote by ID. Returns nil if the emote does not exist
+func (x *EmoteLoader) Load(id primitive.ObjectID) (*datastructure.Emote, error) {
+	v, err := x.l.Load(id)
+	if v == nil || err != nil {
+		return nil, err
+	}
+
+	return v.(*datastructure.Emote), nil
+}
+
+func fetchEmotes(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error) {
+	emotes := []*datastructure.Emote{}
+	if err := cache.Find(ctx, "emotes", "", bson.M{
+		"_id": bson.M{"$in": ids},
+	}, &emotes); err != nil {
+		return nil, err
+	}
+
+	result := make(map[primitive.ObjectID]interface{}, len(emotes))
+	for _, e := range emotes {
+		result[e.ID] = e
+	}
+	return result, nil
+}
+
+type RoleLoader struct {
+	l *batchLoader
+}
+
+// Load a role by ID. Returns nil if the role does not exist
+func (x *RoleLoader) Load(id primitive.ObjectID) (*datastructure.Role, error) {
+	v, err := x.l.Load(id)
+	if v == nil || err != nil {
+		return nil, err
+	}
+
+	return v.(*datastructure.Role), nil
+}
+
+// Roles are held in memory, only the roles missing from memory (i.e created since the last refresh) are queried
+func fetchRoles(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error) {
+	result := make(map[primitive.ObjectID]interface{}, len(ids))
+
+	cached, _ := mongocache.GetRoles().([]datastructure.Role)
+	for _, r := range cached {
+		role := r
+		result[role.ID] = &role
+	}
+
+	missing := []primitive.ObjectID{}
+	for _, id := range ids {
+		if _, ok := result[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	roles := []*datastructure.Role{}
+	cur, err := mongo.Collection(mongo.CollectionNameRoles).Find(ctx, bson.M{
+		"_id": bson.M{"$in": missing},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+
+	for _, r := range roles {
+		result[r.ID] = r
+	}
+	return result, nil
+}
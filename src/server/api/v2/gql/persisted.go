@@ -0,0 +1,191 @@
+package gql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// The Automatic Persisted Query extension of a request, letting clients send the hash of a query instead of its text
+type PersistedQueryExtension struct {
+	Version    int    `json:"version"`
+	Sha256Hash string `json:"sha256Hash"`
+}
+
+// Pre-registered operations, by the SHA-256 hash of their text
+var registeredQueries = map[string]string{}
+
+// The names of the pre-registered operations
+var registeredOperations = map[string]bool{}
+
+var operationNameRegex = regexp.MustCompile(`\b(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// Load the pre-registered operations from the manifest, a JSON object of query text by SHA-256 hash
+func loadRegisteredQueries() {
+	path := configure.Config.GetString("gql.persisted_queries.manifest")
+	if path == "" {
+		return
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		log.WithError(err).WithField("path", path).Fatal("gql, could not read persisted query manifest")
+	}
+
+	manifest := map[string]string{}
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		log.WithError(err).WithField("path", path).Fatal("gql, bad persisted query manifest")
+	}
+
+	for hash, query := range manifest {
+		hash = strings.ToLower(hash)
+		if hashQuery(query) != hash {
+			log.WithField("hash", hash).Warn("gql, persisted query manifest entry does not match its hash")
+			continue
+		}
+		registeredQueries[hash] = query
+		for _, m := range operationNameRegex.FindAllStringSubmatch(query, -1) {
+			registeredOperations[m[1]] = true
+		}
+	}
+	log.WithField("count", len(registeredQueries)).Info("gql, loaded persisted queries")
+}
+
+// The label of an operation in the metrics. Clients name operations freely, so only known names are used
+func operationLabel(name string) string {
+	if name == "" {
+		return "anonymous"
+	}
+	if registeredOperations[name] {
+		return name
+	}
+	for _, op := range configure.Config.GetStringSlice("gql.metrics_operations") {
+		if op == name {
+			return name
+		}
+	}
+
+	return "other"
+}
+
+func hashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// How long a query persisted by a client is kept since its last use
+func getPersistedQueryTTL() time.Duration {
+	ttl := configure.Config.GetDuration("gql.persisted_queries.ttl")
+	if ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+
+	return ttl
+}
+
+// The largest query, in bytes, a client may persist
+func getPersistedQueryMaxSize() int {
+	size := configure.Config.GetInt("gql.persisted_queries.max_size")
+	if size <= 0 {
+		size = 16 * 1024
+	}
+
+	return size
+}
+
+// Whether only pre-registered operations may be executed
+func isStrictPersistedQueries() bool {
+	return configure.Config.GetBool("gql.persisted_queries.strict")
+}
+
+var (
+	errPersistedQueryNotFound = &errors.QueryError{
+		Message:    "PersistedQueryNotFound",
+		Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_FOUND"},
+	}
+	errPersistedQueryMismatch = &errors.QueryError{
+		Message:    "provided sha does not match query",
+		Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_HASH_MISMATCH"},
+	}
+	errPersistedQueryVersion = &errors.QueryError{
+		Message:    "Unsupported persisted query version",
+		Extensions: map[string]interface{}{"code": "PERSISTED_QUERY_NOT_SUPPORTED"},
+	}
+	errOperationNotAllowed = &errors.QueryError{
+		Message:    "Operation Not Allowed",
+		Extensions: map[string]interface{}{"code": "OPERATION_NOT_ALLOWED"},
+	}
+)
+
+// Fill in the text of a request's persisted query, persisting it on first use if it is valid against the schema.
+// In strict mode, only pre-registered operations are let through
+func resolvePersistedQuery(ctx context.Context, schema *graphql.Schema, req *GQLRequest) *errors.QueryError {
+	ext := req.Extensions.PersistedQuery
+	strict := isStrictPersistedQueries()
+
+	if ext == nil {
+		if strict {
+			if _, ok := registeredQueries[hashQuery(req.Query)]; !ok {
+				return errOperationNotAllowed
+			}
+		}
+		return nil
+	}
+	if ext.Version != 1 {
+		return errPersistedQueryVersion
+	}
+
+	hash := strings.ToLower(ext.Sha256Hash)
+	if req.Query != "" {
+		if hashQuery(req.Query) != hash {
+			return errPersistedQueryMismatch
+		}
+		if _, ok := registeredQueries[hash]; ok {
+			return nil
+		}
+		if strict {
+			return errOperationNotAllowed
+		}
+		// Queries too large or invalid still run, as sent, but aren't persisted. The execution reports invalid ones
+		if len(req.Query) > getPersistedQueryMaxSize() {
+			return nil
+		}
+		if errs := schema.Validate(req.Query); len(errs) > 0 {
+			return nil
+		}
+
+		if err := redis.SetPersistedQuery(ctx, hash, req.Query, getPersistedQueryTTL()); err != nil {
+			log.WithError(err).Error("redis")
+		}
+		return nil
+	}
+
+	if query, ok := registeredQueries[hash]; ok {
+		req.Query = query
+		return nil
+	}
+	if strict {
+		return errOperationNotAllowed
+	}
+
+	query, err := redis.GetPersistedQuery(ctx, hash, getPersistedQueryTTL())
+	if err != nil {
+		if err != redis.ErrNil {
+			log.WithError(err).Error("redis")
+		}
+		return errPersistedQueryNotFound
+	}
+
+	req.Query = query
+	return nil
+}
@@ -2,6 +2,8 @@ package resolvers
 
 import (
 	"fmt"
+
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
 )
 
 var (
@@ -15,6 +17,7 @@ var (
 	ErrUnknownChannel        = fmt.Errorf("Unknown Channel")
 	ErrUnknownUser           = fmt.Errorf("Unknown User")
 	ErrUnknownRole           = fmt.Errorf("Unknown Role")
+	ErrUnknownEmoteSet       = fmt.Errorf("Unknown Emote Set")
 	ErrAccessDenied          = fmt.Errorf("Insufficient Privilege")
 	ErrUserBanned            = fmt.Errorf("User Is Banned")
 	ErrUserNotBanned         = fmt.Errorf("User Is Not Banned")
@@ -24,7 +27,30 @@ var (
 	ErrDepth                 = fmt.Errorf("Max Depth Exceeded (%v)", MaxDepth)
 	ErrQueryLimit            = fmt.Errorf("Max Query Limit Exceeded (%v)", QueryLimit)
 	ErrInvalidSortOrder      = fmt.Errorf("SortOrder is either 0 (descending) or 1 (ascending)")
+	ErrInvalidCursor         = fmt.Errorf("Invalid Cursor")
+	ErrUnknownReport         = fmt.Errorf("Unknown Report")
+	ErrUnknownAuditLog       = fmt.Errorf("Unknown Audit Log")
+	ErrReportResolved        = fmt.Errorf("Report Already Resolved")
+	ErrUnknownAccessToken    = fmt.Errorf("Unknown Access Token")
+	ErrInvalidScope          = fmt.Errorf("Invalid Scope")
+	ErrSessionRequired       = fmt.Errorf("Not Available To Access Tokens")
+	ErrMissingCaller         = fmt.Errorf("A User ID, Token ID or IP Is Required")
 	ErrEmoteSlotLimitReached = func(count int32) error {
 		return fmt.Errorf("Channel Emote Slots Limit Reached (%d)", count)
 	}
+	ErrEmoteSetLimitReached = func(count int32) error {
+		return fmt.Errorf("Emote Set Limit Reached (%d)", count)
+	}
+	ErrAccessTokenLimitReached = func(count int64) error {
+		return fmt.Errorf("Access Token Limit Reached (%d)", count)
+	}
+	ErrQueryTooComplex = func(cost int64, budget int64) error {
+		return fmt.Errorf("Query Too Complex (cost %d exceeds budget %d)", cost, budget)
+	}
+	ErrMissingScope = func(scope datastructure.AccessTokenScope) error {
+		return fmt.Errorf("Access Token Missing Scope (%s)", scope)
+	}
+	ErrTooManyChannels = func(limit int) error {
+		return fmt.Errorf("Too Many Channels (%d)", limit)
+	}
 )
@@ -0,0 +1,170 @@
+package mutation_resolvers
+
+import (
+	"context"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	query_resolvers "github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers/query"
+	"github.com/SevenTV/ServerGo/src/utils"
+	"github.com/SevenTV/ServerGo/src/validation"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type createdAccessTokenResolver struct {
+	token       string
+	accessToken *query_resolvers.AccessTokenResolver
+}
+
+func (r *createdAccessTokenResolver) Token() string {
+	return r.token
+}
+
+func (r *createdAccessTokenResolver) AccessToken() *query_resolvers.AccessTokenResolver {
+	return r.accessToken
+}
+
+//
+// CREATE ACCESS TOKEN
+//
+func (*MutationResolver) CreateAccessToken(ctx context.Context, args struct {
+	Name   string
+	Scopes []string
+}) (*createdAccessTokenResolver, error) {
+	// Tokens may not issue more tokens
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+
+	if !validation.ValidateAccessTokenName(utils.S2B(args.Name)) {
+		return nil, resolvers.ErrInvalidName
+	}
+
+	scopes, err := parseAccessTokenScopes(args.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := actions.AccessTokens.Count(ctx, usr.ID)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+	if limit := actions.AccessTokens.GetLimit(); count >= limit {
+		return nil, resolvers.ErrAccessTokenLimitReached(limit)
+	}
+
+	raw, token, err := actions.AccessTokens.Create(ctx, usr, args.Name, scopes)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeUserAccessTokenCreate,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &usr.ID, Type: "users"},
+		Changes: []*datastructure.AuditLogChange{
+			{Key: "access_tokens", OldValue: nil, NewValue: token.ID},
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return &createdAccessTokenResolver{
+		token:       raw,
+		accessToken: query_resolvers.GenerateAccessTokenResolver(token),
+	}, nil
+}
+
+//
+// REVOKE ACCESS TOKEN
+//
+func (*MutationResolver) RevokeAccessToken(ctx context.Context, args struct {
+	ID string
+}) (*response, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, resolvers.ErrUnknownAccessToken
+	}
+
+	token, err := actions.AccessTokens.Revoke(ctx, usr.ID, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, resolvers.ErrUnknownAccessToken
+		}
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeUserAccessTokenRevoke,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &usr.ID, Type: "users"},
+		Changes: []*datastructure.AuditLogChange{
+			{Key: "access_tokens", OldValue: token.ID, NewValue: nil},
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return &response{
+		OK:      true,
+		Status:  200,
+		Message: "success",
+	}, nil
+}
+
+// Parse a list of scopes, rejecting unknown and duplicate ones
+func parseAccessTokenScopes(list []string) ([]datastructure.AccessTokenScope, error) {
+	if len(list) == 0 {
+		return nil, resolvers.ErrInvalidScope
+	}
+
+	scopes := []datastructure.AccessTokenScope{}
+	for _, s := range list {
+		scope := datastructure.AccessTokenScope(s)
+		known := false
+		for _, v := range datastructure.AccessTokenScopes {
+			if v == scope {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, resolvers.ErrInvalidScope
+		}
+
+		duplicate := false
+		for _, v := range scopes {
+			if v == scope {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes, nil
+}
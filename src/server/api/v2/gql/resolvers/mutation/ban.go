@@ -22,6 +22,10 @@ func (*MutationResolver) BanUser(ctx context.Context, args struct {
 	ExpireAt *string
 	Reason   *string
 }) (*response, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -97,7 +101,7 @@ func (*MutationResolver) BanUser(ctx context.Context, args struct {
 		return nil, resolvers.ErrInternalServer
 	}
 
-	actions.Bans.BannedUsers[id] = ban
+	actions.Bans.Add(ctx, ban)
 	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
 		Type:      datastructure.AuditLogTypeUserBan,
 		CreatedBy: usr.ID,
@@ -125,6 +129,10 @@ func (*MutationResolver) UnbanUser(ctx context.Context, args struct {
 	VictimID string
 	Reason   *string
 }) (*response, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -181,7 +189,7 @@ func (*MutationResolver) UnbanUser(ctx context.Context, args struct {
 		return nil, resolvers.ErrInternalServer
 	}
 
-	delete(actions.Bans.BannedUsers, id)
+	actions.Bans.Remove(ctx, id)
 	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
 		Type:      datastructure.AuditLogTypeUserUnban,
 		CreatedBy: usr.ID,
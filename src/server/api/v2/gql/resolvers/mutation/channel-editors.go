@@ -23,6 +23,10 @@ func (*MutationResolver) AddChannelEditor(ctx context.Context, args struct {
 	EditorID  string
 	Reason    *string
 }) (*query_resolvers.UserResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEditorsWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -121,6 +125,10 @@ func (*MutationResolver) RemoveChannelEditor(ctx context.Context, args struct {
 	EditorID  string
 	Reason    *string
 }) (*query_resolvers.UserResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEditorsWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -24,6 +24,10 @@ func (*MutationResolver) AddChannelEmote(ctx context.Context, args struct {
 	EmoteID   string
 	Reason    *string
 }) (*query_resolvers.UserResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeChannelEmotesWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -133,6 +137,9 @@ func (*MutationResolver) AddChannelEmote(ctx context.Context, args struct {
 		log.WithError(err).Error("mongo")
 		return nil, resolvers.ErrInternalServer
 	}
+	if err := actions.EmoteSets.SyncActive(ctx, channel); err != nil {
+		log.WithError(err).Error("mongo")
+	}
 
 	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
 		Type:      datastructure.AuditLogTypeUserChannelEmoteAdd,
@@ -173,7 +180,7 @@ func (*MutationResolver) AddChannelEmote(ctx context.Context, args struct {
 			log.WithError(err).Error("mongo")
 		}
 
-		_ = redis.Publish(context.Background(), fmt.Sprintf("events-v1:channel-emotes:%s", channel.Login), redis.EventApiV1ChannelEmotes{
+		_ = redis.PublishChannelEmotesEvent(context.Background(), channel.Login, redis.EventApiV1ChannelEmotes{
 			Channel: channel.Login,
 			EmoteID: emoteID.Hex(),
 			Name:    name,
@@ -209,6 +216,10 @@ func (*MutationResolver) EditChannelEmote(ctx context.Context, args struct {
 	}
 	Reason *string
 }) (*query_resolvers.UserResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeChannelEmotesWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -373,7 +384,7 @@ func (*MutationResolver) EditChannelEmote(ctx context.Context, args struct {
 			log.WithError(err).Error("mongo")
 		}
 
-		_ = redis.Publish(context.Background(), fmt.Sprintf("events-v1:channel-emotes:%s", channel.Login), redis.EventApiV1ChannelEmotes{
+		_ = redis.PublishChannelEmotesEvent(context.Background(), channel.Login, redis.EventApiV1ChannelEmotes{
 			Channel: channel.Login,
 			EmoteID: emoteID.Hex(),
 			Name:    newName,
@@ -406,6 +417,10 @@ func (*MutationResolver) RemoveChannelEmote(ctx context.Context, args struct {
 	EmoteID   string
 	Reason    *string
 }) (*query_resolvers.UserResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeChannelEmotesWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -516,6 +531,9 @@ func (*MutationResolver) RemoveChannelEmote(ctx context.Context, args struct {
 		log.WithError(err).Error("mongo")
 		return nil, resolvers.ErrInternalServer
 	}
+	if err := actions.EmoteSets.SyncActive(ctx, channel); err != nil {
+		log.WithError(err).Error("mongo")
+	}
 
 	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
 		Type:      datastructure.AuditLogTypeUserChannelEmoteRemove,
@@ -543,7 +561,7 @@ func (*MutationResolver) RemoveChannelEmote(ctx context.Context, args struct {
 			oldName = v
 		}
 
-		_ = redis.Publish(context.Background(), fmt.Sprintf("events-v1:channel-emotes:%s", channel.Login), redis.EventApiV1ChannelEmotes{
+		_ = redis.PublishChannelEmotesEvent(context.Background(), channel.Login, redis.EventApiV1ChannelEmotes{
 			Channel: channel.Login,
 			EmoteID: emoteID.Hex(),
 			Name:    oldName,
@@ -19,6 +19,10 @@ func (*MutationResolver) DeleteEmote(ctx context.Context, args struct {
 	ID     string
 	Reason string
 }) (*bool, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesWrite); err != nil {
+		return nil, err
+	}
+
 	if args.Reason == "" {
 		return nil, resolvers.ErrNoReason
 	}
@@ -110,7 +114,8 @@ func (*MutationResolver) DeleteEmote(ctx context.Context, args struct {
 		}()
 	}
 
-	go discord.SendEmoteDelete(*emote, *usr, args.Reason)
+	e, u := *emote, *usr
+	discord.Go(func() { discord.SendEmoteDelete(e, u, args.Reason) })
 	success = true
 	return &success, nil
 }
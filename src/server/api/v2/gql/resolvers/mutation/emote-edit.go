@@ -25,6 +25,10 @@ func (*MutationResolver) EditEmote(ctx context.Context, args struct {
 	Emote  emoteInput
 	Reason *string
 }) (*query_resolvers.EmoteResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -165,6 +169,7 @@ func (*MutationResolver) EditEmote(ctx context.Context, args struct {
 
 	if len(logChanges) > 0 {
 		update["last_modified_date"] = time.Now()
+		update["edited_at"] = update["last_modified_date"]
 
 		oldVisibility := emote.Visibility
 		after := options.After
@@ -222,7 +227,8 @@ func (*MutationResolver) EditEmote(ctx context.Context, args struct {
 
 		}
 
-		go discord.SendEmoteEdit(*emote, *usr, logChanges, args.Reason)
+		e, u := *emote, *usr
+		discord.Go(func() { discord.SendEmoteEdit(e, u, logChanges, args.Reason) })
 		return query_resolvers.GenerateEmoteResolver(ctx, emote, &emote.ID, field.Children)
 	}
 
@@ -17,6 +17,10 @@ func (*MutationResolver) MergeEmote(ctx context.Context, args struct {
 	NewID  string
 	Reason string
 }) (*query_resolvers.EmoteResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
 	// Get the actor user
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
@@ -6,11 +6,11 @@ import (
 	"sync"
 	"time"
 
-	"github.com/SevenTV/ServerGo/src/aws"
 	"github.com/SevenTV/ServerGo/src/configure"
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	"github.com/SevenTV/ServerGo/src/storage"
 	"github.com/SevenTV/ServerGo/src/utils"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
@@ -21,6 +21,10 @@ func (*MutationResolver) RestoreEmote(ctx context.Context, args struct {
 	ID     string
 	Reason *string
 }) (*response, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -72,6 +76,7 @@ func (*MutationResolver) RestoreEmote(ctx context.Context, args struct {
 		"$set": bson.M{
 			"status":             datastructure.EmoteStatusProcessing,
 			"last_modified_date": time.Now(),
+			"edited_at":          time.Now(),
 		},
 	})
 
@@ -87,7 +92,7 @@ func (*MutationResolver) RestoreEmote(ctx context.Context, args struct {
 		go func(i int) {
 			defer wg.Done()
 			obj := fmt.Sprintf("emote/%s", emote.ID.Hex())
-			err := aws.Unexpire(configure.Config.GetString("aws_cdn_bucket"), obj, i)
+			err := storage.Unexpire(configure.Config.GetString("aws_cdn_bucket"), obj, i)
 			if err != nil {
 				log.WithError(err).WithField("obj", obj).Error("aws")
 			}
@@ -102,6 +107,7 @@ func (*MutationResolver) RestoreEmote(ctx context.Context, args struct {
 		"$set": bson.M{
 			"status":             datastructure.EmoteStatusLive,
 			"last_modified_date": time.Now(),
+			"edited_at":          time.Now(),
 		},
 	})
 	if err != nil {
@@ -0,0 +1,415 @@
+package mutation_resolvers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	query_resolvers "github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers/query"
+	"github.com/SevenTV/ServerGo/src/utils"
+	"github.com/SevenTV/ServerGo/src/validation"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Mutate Emote Set - Create
+func (*MutationResolver) CreateEmoteSet(ctx context.Context, args struct {
+	ChannelID string
+	Name      string
+	Reason    *string
+}) (*query_resolvers.EmoteSetResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeChannelEmotesWrite); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+
+	channelID, err := primitive.ObjectIDFromHex(args.ChannelID)
+	if err != nil {
+		return nil, resolvers.ErrUnknownChannel
+	}
+
+	channel, err := getEmoteSetChannel(ctx, usr, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return createEmoteSet(ctx, usr, channel, args.Name, []primitive.ObjectID{}, args.Reason)
+}
+
+// Mutate Emote Set - Clone
+func (*MutationResolver) CloneEmoteSet(ctx context.Context, args struct {
+	ID     string
+	Name   string
+	Reason *string
+}) (*query_resolvers.EmoteSetResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeChannelEmotesWrite); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+
+	set, err := getEmoteSet(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := getEmoteSetChannel(ctx, usr, set.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return createEmoteSet(ctx, usr, channel, args.Name, set.EmoteIDs, args.Reason)
+}
+
+// Mutate Emote Set - Rename
+func (*MutationResolver) RenameEmoteSet(ctx context.Context, args struct {
+	ID     string
+	Name   string
+	Reason *string
+}) (*query_resolvers.EmoteSetResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeChannelEmotesWrite); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+
+	if !validation.ValidateEmoteSetName(utils.S2B(args.Name)) {
+		return nil, resolvers.ErrInvalidName
+	}
+
+	set, err := getEmoteSet(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := getEmoteSetChannel(ctx, usr, set.OwnerID); err != nil {
+		return nil, err
+	}
+
+	field, failed := query_resolvers.GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	oldName := set.Name
+	if err := actions.EmoteSets.Rename(ctx, set, args.Name); err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeUserEmoteSetEdit,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &set.OwnerID, Type: "users"},
+		Changes: []*datastructure.AuditLogChange{
+			{Key: "name", OldValue: oldName, NewValue: set.Name},
+		},
+		Reason: args.Reason,
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return query_resolvers.GenerateEmoteSetResolver(ctx, set, field.Children)
+}
+
+// Mutate Emote Set - Activate
+func (*MutationResolver) ActivateEmoteSet(ctx context.Context, args struct {
+	ID     string
+	Reason *string
+}) (*query_resolvers.UserResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeChannelEmotesWrite); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+
+	set, err := getEmoteSet(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := getEmoteSetChannel(ctx, usr, set.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !usr.HasPermission(datastructure.RolePermissionManageUsers) && len(set.EmoteIDs) > int(channel.GetEmoteSlots()) {
+		return nil, resolvers.ErrEmoteSlotLimitReached(channel.GetEmoteSlots())
+	}
+
+	field, failed := query_resolvers.GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	var oldSetID *primitive.ObjectID
+	if channel.ActiveEmoteSetID != nil {
+		id := *channel.ActiveEmoteSetID
+		oldSetID = &id
+	}
+	if oldSetID != nil && *oldSetID == set.ID {
+		return query_resolvers.GenerateUserResolver(ctx, channel, &channel.ID, field.Children)
+	}
+
+	oldAlias := channel.EmoteAlias
+	added, removed, err := actions.EmoteSets.Activate(ctx, channel, set)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeUserEmoteSetActivate,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &channel.ID, Type: "users"},
+		Changes: []*datastructure.AuditLogChange{
+			{Key: "active_emote_set", OldValue: oldSetID, NewValue: set.ID},
+		},
+		Reason: args.Reason,
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	// Push the changes to the channel's emotes to redis
+	go publishEmoteSetSwitch(channel, usr, added, removed, oldAlias)
+
+	return query_resolvers.GenerateUserResolver(ctx, channel, &channel.ID, field.Children)
+}
+
+func createEmoteSet(ctx context.Context, usr *datastructure.User, channel *datastructure.User, name string, emoteIDs []primitive.ObjectID, reason *string) (*query_resolvers.EmoteSetResolver, error) {
+	if !validation.ValidateEmoteSetName(utils.S2B(name)) {
+		return nil, resolvers.ErrInvalidName
+	}
+
+	if !usr.HasPermission(datastructure.RolePermissionManageUsers) {
+		count, err := actions.EmoteSets.Count(ctx, channel.ID)
+		if err != nil {
+			log.WithError(err).Error("mongo")
+			return nil, resolvers.ErrInternalServer
+		}
+		if count >= int64(channel.GetEmoteSetLimit()) {
+			return nil, resolvers.ErrEmoteSetLimitReached(channel.GetEmoteSetLimit())
+		}
+	}
+
+	field, failed := query_resolvers.GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	set, err := actions.EmoteSets.Create(ctx, channel, name, emoteIDs)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeUserEmoteSetCreate,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &channel.ID, Type: "users"},
+		Changes: []*datastructure.AuditLogChange{
+			{Key: "emote_sets", OldValue: nil, NewValue: set.ID},
+		},
+		Reason: reason,
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return query_resolvers.GenerateEmoteSetResolver(ctx, set, field.Children)
+}
+
+// Get an emote set by its hex ID
+func getEmoteSet(ctx context.Context, hexID string) (*datastructure.EmoteSet, error) {
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, resolvers.ErrUnknownEmoteSet
+	}
+
+	set, err := actions.EmoteSets.Get(ctx, id)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, resolvers.ErrUnknownEmoteSet
+		}
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	return set, nil
+}
+
+// Get the channel owning emote sets, if the actor is allowed to manage them
+func getEmoteSetChannel(ctx context.Context, usr *datastructure.User, channelID primitive.ObjectID) (*datastructure.User, error) {
+	banned, _ := actions.Bans.IsUserBanned(channelID)
+	if banned {
+		return nil, resolvers.ErrUserBanned
+	}
+
+	res := mongo.Collection(mongo.CollectionNameUsers).FindOne(ctx, bson.M{
+		"_id": channelID,
+	})
+	channel := &datastructure.User{}
+	err := res.Err()
+
+	if err == nil {
+		err = res.Decode(channel)
+	}
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, resolvers.ErrUnknownChannel
+		}
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	if !usr.HasPermission(datastructure.RolePermissionManageUsers) {
+		if channel.ID.Hex() != usr.ID.Hex() {
+			found := false
+			for _, e := range channel.EditorIDs {
+				if e.Hex() == usr.ID.Hex() {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, resolvers.ErrAccessDenied
+			}
+		}
+	}
+
+	return channel, nil
+}
+
+// Publish the emotes added and removed by switching a channel's emote set
+func publishEmoteSetSwitch(channel *datastructure.User, actor *datastructure.User, added []primitive.ObjectID, removed []primitive.ObjectID, oldAlias map[string]string) {
+	ctx := context.Background()
+
+	ids := append(append([]primitive.ObjectID{}, added...), removed...)
+	if len(ids) == 0 {
+		return
+	}
+
+	emotes := []*datastructure.Emote{}
+	cur, err := mongo.Collection(mongo.CollectionNameEmotes).Find(ctx, bson.M{
+		"_id": bson.M{"$in": ids},
+	})
+	if err == nil {
+		err = cur.All(ctx, &emotes)
+	}
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return
+	}
+	emoteMap := map[primitive.ObjectID]*datastructure.Emote{}
+	ownerIDs := []primitive.ObjectID{}
+	for _, e := range emotes {
+		emoteMap[e.ID] = e
+		ownerIDs = append(ownerIDs, e.OwnerID)
+	}
+
+	owners := []*datastructure.User{}
+	cur, err = mongo.Collection(mongo.CollectionNameUsers).Find(ctx, bson.M{
+		"_id": bson.M{"$in": ownerIDs},
+	})
+	if err == nil {
+		err = cur.All(ctx, &owners)
+	}
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+	ownerMap := map[primitive.ObjectID]*datastructure.User{}
+	for _, u := range owners {
+		ownerMap[u.ID] = u
+	}
+
+	for _, id := range removed {
+		_ = redis.Publish(ctx, fmt.Sprintf("users:%v:emotes", channel.Login), redis.PubSubPayloadUserEmotes{
+			Removed: true,
+			ID:      id.Hex(),
+			Actor:   actor.DisplayName,
+		})
+
+		name := ""
+		if e, ok := emoteMap[id]; ok {
+			name = e.Name
+		}
+		if v, ok := oldAlias[id.Hex()]; ok {
+			name = v
+		}
+
+		_ = redis.PublishChannelEmotesEvent(ctx, channel.Login, redis.EventApiV1ChannelEmotes{
+			Channel: channel.Login,
+			EmoteID: id.Hex(),
+			Name:    name,
+			Action:  "REMOVE",
+			Actor:   actor.DisplayName,
+		})
+	}
+
+	for _, id := range added {
+		emote, ok := emoteMap[id]
+		if !ok {
+			continue
+		}
+
+		_ = redis.Publish(ctx, fmt.Sprintf("users:%v:emotes", channel.Login), redis.PubSubPayloadUserEmotes{
+			Removed: false,
+			ID:      id.Hex(),
+			Actor:   actor.DisplayName,
+		})
+
+		name := emote.Name
+		if v, ok := channel.EmoteAlias[id.Hex()]; ok {
+			name = v
+		}
+
+		owner := ownerMap[emote.OwnerID]
+		if owner == nil {
+			owner = &datastructure.User{}
+		}
+
+		_ = redis.PublishChannelEmotesEvent(ctx, channel.Login, redis.EventApiV1ChannelEmotes{
+			Channel: channel.Login,
+			EmoteID: id.Hex(),
+			Name:    name,
+			Action:  "ADD",
+			Actor:   actor.DisplayName,
+			Emote: &redis.EventApiV1ChannelEmotesEmote{
+				Name:       emote.Name,
+				Visibility: emote.Visibility,
+				MIME:       emote.Mime,
+				Tags:       emote.Tags,
+				Width:      emote.Width,
+				Height:     emote.Height,
+				Animated:   emote.Animated,
+				URLs:       datastructure.GetEmoteURLs(*emote),
+				Owner: redis.EventApiV1ChannelEmotesEmoteOwner{
+					ID:          emote.OwnerID.Hex(),
+					TwitchID:    owner.TwitchID,
+					DisplayName: owner.DisplayName,
+					Login:       owner.Login,
+				},
+			},
+		})
+	}
+}
@@ -18,6 +18,10 @@ import (
 func (*MutationResolver) DeleteEntitlement(ctx context.Context, args struct {
 	ID string
 }) (*response, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
 	// Get actor reference
 	actor, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
@@ -56,6 +60,10 @@ func (*MutationResolver) CreateEntitlement(ctx context.Context, args struct {
 	UserID   string
 	Disabled *bool
 }) (*response, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
 	// Get actor reference
 	actor, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
@@ -2,18 +2,40 @@ package mutation_resolvers
 
 import (
 	"context"
+	"time"
 
+	"github.com/SevenTV/ServerGo/src/configure"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
 	"github.com/SevenTV/ServerGo/src/redis"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
 	"github.com/SevenTV/ServerGo/src/utils"
+	log "github.com/sirupsen/logrus"
 )
 
+func init() {
+	// Changing the featured broadcast in the config file features it like editing the app would
+	configure.Subscribe(configure.Subscription{
+		Keys: []string{"featured_broadcast"},
+		OnChange: func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+			defer cancel()
+
+			if err := redis.Client.Set(ctx, "meta:featured_broadcast", configure.Config.GetString("featured_broadcast"), 0).Err(); err != nil {
+				log.WithError(err).Error("redis")
+			}
+		},
+	})
+}
+
 func (*MutationResolver) EditApp(ctx context.Context, args struct {
 	Properties struct {
 		FeaturedBroadcast *string
 	}
 }) (*response, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -16,6 +16,10 @@ import (
 func (*MutationResolver) MarkNotificationsRead(ctx context.Context, args struct {
 	NotificationIDs []string
 }) (*response, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeUsersWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -2,11 +2,13 @@ package mutation_resolvers
 
 import (
 	"context"
+	"time"
 
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
 	"github.com/SevenTV/ServerGo/src/server/api/actions"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	query_resolvers "github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers/query"
 	"github.com/SevenTV/ServerGo/src/utils"
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
@@ -21,6 +23,10 @@ func (*MutationResolver) ReportEmote(ctx context.Context, args struct {
 	EmoteID string
 	Reason  *string
 }) (*response, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeReportsWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -97,6 +103,10 @@ func (*MutationResolver) ReportUser(ctx context.Context, args struct {
 	UserID string
 	Reason *string
 }) (*response, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeReportsWrite); err != nil {
+		return nil, err
+	}
+
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
 		return nil, resolvers.ErrLoginRequired
@@ -116,7 +126,7 @@ func (*MutationResolver) ReportUser(ctx context.Context, args struct {
 		return nil, resolvers.ErrUserBanned
 	}
 
-	res := mongo.Database.Collection("user").FindOne(ctx, bson.M{
+	res := mongo.Collection(mongo.CollectionNameUsers).FindOne(ctx, bson.M{
 		"_id": id,
 	})
 
@@ -160,7 +170,7 @@ func (*MutationResolver) ReportUser(ctx context.Context, args struct {
 	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
 		Type:      datastructure.AuditLogTypeReport,
 		CreatedBy: usr.ID,
-		Target:    &datastructure.Target{ID: &id, Type: "emotes"},
+		Target:    &datastructure.Target{ID: &id, Type: "users"},
 		Changes:   nil,
 		Reason:    args.Reason,
 	})
@@ -174,3 +184,284 @@ func (*MutationResolver) ReportUser(ctx context.Context, args struct {
 		Message: "success",
 	}, nil
 }
+
+//
+// ASSIGN REPORT
+//
+func (*MutationResolver) AssignReport(ctx context.Context, args struct {
+	ReportID   string
+	AssigneeID *string
+}) (*query_resolvers.ReportResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+	if !usr.HasPermission(datastructure.RolePermissionManageReports) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	report, err := getReport(ctx, args.ReportID)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$unset": bson.M{"assignee_id": ""}}
+	var assigneeID *primitive.ObjectID
+	if args.AssigneeID != nil && *args.AssigneeID != "" {
+		id, err := primitive.ObjectIDFromHex(*args.AssigneeID)
+		if err != nil {
+			return nil, resolvers.ErrUnknownUser
+		}
+
+		// Reports can only be assigned to moderators able to handle them
+		assignee := &datastructure.User{}
+		if err := mongo.Collection(mongo.CollectionNameUsers).FindOne(ctx, bson.M{"_id": id}).Decode(assignee); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, resolvers.ErrUnknownUser
+			}
+			log.WithError(err).Error("mongo")
+			return nil, resolvers.ErrInternalServer
+		}
+		if !assignee.HasPermission(datastructure.RolePermissionManageReports) {
+			return nil, resolvers.ErrAccessDenied
+		}
+
+		assigneeID = &id
+		update = bson.M{"$set": bson.M{"assignee_id": id}}
+	}
+
+	report, err = updateReport(ctx, report.ID, update)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeReportEdit,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &report.ID, Type: "reports"},
+		Changes: []*datastructure.AuditLogChange{
+			{Key: "assignee_id", OldValue: nil, NewValue: assigneeID},
+		},
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return generateReportResolver(ctx, report)
+}
+
+//
+// ADD REPORT NOTE
+//
+func (*MutationResolver) AddReportNote(ctx context.Context, args struct {
+	ReportID string
+	Content  string
+}) (*query_resolvers.ReportResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+	if !usr.HasPermission(datastructure.RolePermissionManageReports) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	if args.Content == "" || len(args.Content) > 2000 {
+		return nil, resolvers.ErrInvalidUpdate
+	}
+
+	report, err := getReport(ctx, args.ReportID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err = updateReport(ctx, report.ID, bson.M{
+		"$push": bson.M{
+			"notes": &datastructure.ReportNote{
+				AuthorID:  usr.ID,
+				Content:   args.Content,
+				CreatedAt: time.Now(),
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return generateReportResolver(ctx, report)
+}
+
+//
+// RESOLVE REPORT
+//
+func (*MutationResolver) ResolveReport(ctx context.Context, args struct {
+	ReportID   string
+	Outcome    string
+	AuditLogID *string
+	Reason     *string
+}) (*query_resolvers.ReportResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+	if !usr.HasPermission(datastructure.RolePermissionManageReports) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	report, err := getReport(ctx, args.ReportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Cleared {
+		return nil, resolvers.ErrReportResolved
+	}
+
+	// The audit log types of the action taken for each outcome
+	outcome := datastructure.ReportOutcome(args.Outcome)
+	var (
+		logType    int32
+		targetType string
+	)
+	switch outcome {
+	case datastructure.ReportOutcomeDismissed:
+	case datastructure.ReportOutcomeEmoteDeleted:
+		logType, targetType = datastructure.AuditLogTypeEmoteDelete, "emotes"
+	case datastructure.ReportOutcomeEmoteEdited:
+		logType, targetType = datastructure.AuditLogTypeEmoteEdit, "emotes"
+	case datastructure.ReportOutcomeUserBanned:
+		logType, targetType = datastructure.AuditLogTypeUserBan, "users"
+	default:
+		return nil, resolvers.ErrInvalidUpdate
+	}
+
+	// Link the audit log of the action taken on the reported target.
+	// Without a specified log, the latest matching log is used
+	var auditLogID *primitive.ObjectID
+	if logType != 0 {
+		if report.Target == nil || report.Target.Type != targetType {
+			return nil, resolvers.ErrInvalidUpdate
+		}
+
+		filter := bson.M{
+			"type":        logType,
+			"target.type": targetType,
+			"target.id":   report.Target.ID,
+		}
+		if args.AuditLogID != nil {
+			id, err := primitive.ObjectIDFromHex(*args.AuditLogID)
+			if err != nil {
+				return nil, resolvers.ErrUnknownAuditLog
+			}
+			filter["_id"] = id
+		}
+
+		l := &datastructure.AuditLog{}
+		if err := mongo.Collection(mongo.CollectionNameAudit).FindOne(ctx, filter, options.FindOne().SetSort(bson.M{"_id": -1})).Decode(l); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, resolvers.ErrUnknownAuditLog
+			}
+			log.WithError(err).Error("mongo")
+			return nil, resolvers.ErrInternalServer
+		}
+		auditLogID = &l.ID
+	}
+
+	// Only match a report still open, so that of two moderators resolving at once only one succeeds
+	after := options.After
+	if err := mongo.Collection(mongo.CollectionNameReports).FindOneAndUpdate(ctx, bson.M{
+		"_id":     report.ID,
+		"cleared": false,
+	}, bson.M{
+		"$set": bson.M{
+			"cleared": true,
+			"resolution": &datastructure.ReportResolution{
+				Outcome:    outcome,
+				ResolvedBy: usr.ID,
+				ResolvedAt: time.Now(),
+				AuditLogID: auditLogID,
+			},
+		},
+	}, &options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}).Decode(report); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, resolvers.ErrReportResolved
+		}
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeReportClear,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &report.ID, Type: "reports"},
+		Changes: []*datastructure.AuditLogChange{
+			{Key: "outcome", OldValue: nil, NewValue: outcome},
+			{Key: "audit_log_id", OldValue: nil, NewValue: auditLogID},
+		},
+		Reason: args.Reason,
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return generateReportResolver(ctx, report)
+}
+
+// Get a report by its hex ID
+func getReport(ctx context.Context, hexID string) (*datastructure.Report, error) {
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, resolvers.ErrUnknownReport
+	}
+
+	report := &datastructure.Report{}
+	if err := mongo.Collection(mongo.CollectionNameReports).FindOne(ctx, bson.M{"_id": id}).Decode(report); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, resolvers.ErrUnknownReport
+		}
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	return report, nil
+}
+
+// Apply an update to a report, returning the updated report
+func updateReport(ctx context.Context, id primitive.ObjectID, update bson.M) (*datastructure.Report, error) {
+	report := &datastructure.Report{}
+	after := options.After
+	if err := mongo.Collection(mongo.CollectionNameReports).FindOneAndUpdate(ctx, bson.M{
+		"_id": id,
+	}, update, &options.FindOneAndUpdateOptions{
+		ReturnDocument: &after,
+	}).Decode(report); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, resolvers.ErrUnknownReport
+		}
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	return report, nil
+}
+
+func generateReportResolver(ctx context.Context, report *datastructure.Report) (*query_resolvers.ReportResolver, error) {
+	field, failed := query_resolvers.GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	return query_resolvers.GenerateReportResolver(ctx, report, field.Children)
+}
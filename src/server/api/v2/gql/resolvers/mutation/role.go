@@ -2,19 +2,270 @@ package mutation_resolvers
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
 	query_resolvers "github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers/query"
+	"github.com/SevenTV/ServerGo/src/utils"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type roleInput struct {
-	ID       string      `json:"id"`
-	Name     string      `json:"name"`
-	Color    *int32      `json:"color"`
-	Position *int32      `json:"position"`
-	Allowed  *int64      `json:"allowed"`
-	Denied   *complex128 `json:"denied"`
+	Name     *string `json:"name"`
+	Color    *int32  `json:"color"`
+	Position *int32  `json:"position"`
+	Allowed  *string `json:"allowed"`
+	Denied   *string `json:"denied"`
 }
 
-func (r *MutationResolver) CreateRole(ctx context.Context, args roleInput) (*query_resolvers.RoleResolver, error) {
-	return nil, nil
+//
+// CREATE ROLE
+//
+func (*MutationResolver) CreateRole(ctx context.Context, args struct {
+	Data   roleInput
+	Reason *string
+}) (*query_resolvers.RoleResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+	if !usr.HasPermission(datastructure.RolePermissionManageRoles) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	if args.Data.Name == nil || *args.Data.Name == "" {
+		return nil, resolvers.ErrInvalidName
+	}
+
+	role := &datastructure.Role{}
+	changes, err := applyRoleInput(usr, role, args.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := actions.Roles.Create(ctx, role); err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeRoleCreate,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &role.ID, Type: "roles"},
+		Changes:   changes,
+		Reason:    args.Reason,
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return query_resolvers.GenerateRoleResolver(ctx, role, nil, nil)
+}
+
+//
+// EDIT ROLE
+//
+func (*MutationResolver) EditRole(ctx context.Context, args struct {
+	RoleID string
+	Data   roleInput
+	Reason *string
+}) (*query_resolvers.RoleResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+	if !usr.HasPermission(datastructure.RolePermissionManageRoles) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	role, err := getManagedRole(usr, args.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if args.Data.Name != nil && *args.Data.Name == "" {
+		return nil, resolvers.ErrInvalidName
+	}
+
+	changes, err := applyRoleInput(usr, role, args.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return query_resolvers.GenerateRoleResolver(ctx, role, nil, nil)
+	}
+
+	set := bson.M{}
+	for _, c := range changes {
+		set[c.Key] = c.NewValue
+	}
+	if err := actions.Roles.Edit(ctx, role, bson.M{"$set": set}); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, resolvers.ErrUnknownRole
+		}
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeRoleEdit,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &role.ID, Type: "roles"},
+		Changes:   changes,
+		Reason:    args.Reason,
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return query_resolvers.GenerateRoleResolver(ctx, role, nil, nil)
+}
+
+//
+// DELETE ROLE
+//
+func (*MutationResolver) DeleteRole(ctx context.Context, args struct {
+	RoleID string
+	Reason *string
+}) (*response, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+	if !usr.HasPermission(datastructure.RolePermissionManageRoles) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	role, err := getManagedRole(usr, args.RoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Removing a role takes its permissions from its users, which requires holding them
+	if ok, flag := actions.Roles.CanGrant(usr, role.Allowed|role.Denied); !ok {
+		return nil, fmt.Errorf("Cannot Revoke Permission Not Held (%d)", flag)
+	}
+
+	if err := actions.Roles.Delete(ctx, role); err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(ctx, &datastructure.AuditLog{
+		Type:      datastructure.AuditLogTypeRoleDelete,
+		CreatedBy: usr.ID,
+		Target:    &datastructure.Target{ID: &role.ID, Type: "roles"},
+		Changes: []*datastructure.AuditLogChange{
+			{Key: "name", OldValue: role.Name, NewValue: nil},
+		},
+		Reason: args.Reason,
+	})
+	if err != nil {
+		log.WithError(err).Error("mongo")
+	}
+
+	return &response{
+		OK:      true,
+		Status:  200,
+		Message: "success",
+	}, nil
+}
+
+// Get a role which the actor is above in the hierarchy
+func getManagedRole(usr *datastructure.User, hexID string) (*datastructure.Role, error) {
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, resolvers.ErrUnknownRole
+	}
+
+	role := datastructure.GetRole(&id)
+	if role.Default || role.ID != id {
+		return nil, resolvers.ErrUnknownRole
+	}
+	if usr.Role.Position <= role.Position {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	return &role, nil
+}
+
+// Apply a role input to a role, checking the actor may make the changes.
+// Returns the changes made
+func applyRoleInput(usr *datastructure.User, role *datastructure.Role, data roleInput) ([]*datastructure.AuditLogChange, error) {
+	changes := []*datastructure.AuditLogChange{}
+
+	if data.Name != nil && *data.Name != role.Name {
+		changes = append(changes, &datastructure.AuditLogChange{Key: "name", OldValue: role.Name, NewValue: *data.Name})
+		role.Name = *data.Name
+	}
+	if data.Color != nil && *data.Color != role.Color {
+		changes = append(changes, &datastructure.AuditLogChange{Key: "color", OldValue: role.Color, NewValue: *data.Color})
+		role.Color = *data.Color
+	}
+	if data.Position != nil && *data.Position != role.Position {
+		// Roles can't be moved to or above the actor's own role
+		if *data.Position >= usr.Role.Position || *data.Position < 0 {
+			return nil, resolvers.ErrAccessDenied
+		}
+
+		changes = append(changes, &datastructure.AuditLogChange{Key: "position", OldValue: role.Position, NewValue: *data.Position})
+		role.Position = *data.Position
+	}
+
+	// Permissions: the actor must hold every permission they grant, deny or revoke
+	changed := int64(0)
+	if data.Allowed != nil {
+		allowed, err := parsePermissions(*data.Allowed)
+		if err != nil {
+			return nil, err
+		}
+		if allowed != role.Allowed {
+			changed |= allowed ^ role.Allowed
+			changes = append(changes, &datastructure.AuditLogChange{Key: "allowed", OldValue: role.Allowed, NewValue: allowed})
+			role.Allowed = allowed
+		}
+	}
+	if data.Denied != nil {
+		denied, err := parsePermissions(*data.Denied)
+		if err != nil {
+			return nil, err
+		}
+		if denied != role.Denied {
+			changed |= denied ^ role.Denied
+			changes = append(changes, &datastructure.AuditLogChange{Key: "denied", OldValue: role.Denied, NewValue: denied})
+			role.Denied = denied
+		}
+	}
+	if ok, flag := actions.Roles.CanGrant(usr, changed); !ok {
+		return nil, fmt.Errorf("Cannot Grant Permission Not Held (%d)", flag)
+	}
+
+	return changes, nil
+}
+
+// Parse a permission bitset, rejecting unknown permissions
+func parsePermissions(s string) (int64, error) {
+	bits, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || bits < 0 || bits&^datastructure.RolePermissionAll != 0 {
+		return 0, resolvers.ErrInvalidUpdate
+	}
+
+	return bits, nil
 }
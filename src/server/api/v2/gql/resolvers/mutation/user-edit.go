@@ -21,6 +21,10 @@ func (*MutationResolver) EditUser(ctx context.Context, args struct {
 	User   userInput
 	Reason *string
 }) (*query_resolvers.UserResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeUsersWrite); err != nil {
+		return nil, err
+	}
+
 	// Get the actor user
 	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok {
@@ -60,6 +64,7 @@ func (*MutationResolver) EditUser(ctx context.Context, args struct {
 		// Role is empty?
 		if *req.RoleID == "" {
 			update["role"] = nil
+			update["role_position"] = nil
 			logChanges = append(logChanges, &datastructure.AuditLogChange{
 				Key:      "role",
 				OldValue: target.RoleID,
@@ -82,6 +87,7 @@ func (*MutationResolver) EditUser(ctx context.Context, args struct {
 
 			// Update role
 			update["role"] = role.ID
+			update["role_position"] = role.Position
 			logChanges = append(logChanges, &datastructure.AuditLogChange{
 				Key:      "role",
 				OldValue: target.RoleID,
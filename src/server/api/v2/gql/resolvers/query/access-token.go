@@ -0,0 +1,72 @@
+package query_resolvers
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	"github.com/SevenTV/ServerGo/src/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+func (*QueryResolver) AccessTokens(ctx context.Context) ([]*AccessTokenResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, ok := ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrLoginRequired
+	}
+
+	tokens, err := actions.AccessTokens.List(ctx, usr.ID)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	result := make([]*AccessTokenResolver, len(tokens))
+	for i, token := range tokens {
+		result[i] = GenerateAccessTokenResolver(token)
+	}
+	return result, nil
+}
+
+type AccessTokenResolver struct {
+	v *datastructure.AccessToken
+}
+
+func GenerateAccessTokenResolver(token *datastructure.AccessToken) *AccessTokenResolver {
+	return &AccessTokenResolver{v: token}
+}
+
+func (r *AccessTokenResolver) ID() string {
+	return r.v.ID.Hex()
+}
+
+func (r *AccessTokenResolver) Name() string {
+	return r.v.Name
+}
+
+func (r *AccessTokenResolver) Scopes() []string {
+	scopes := make([]string, len(r.v.Scopes))
+	for i, scope := range r.v.Scopes {
+		scopes[i] = string(scope)
+	}
+	return scopes
+}
+
+func (r *AccessTokenResolver) CreatedAt() string {
+	return r.v.CreatedAt.Format(time.RFC3339)
+}
+
+func (r *AccessTokenResolver) LastUsedAt() *string {
+	if r.v.LastUsedAt == nil {
+		return nil
+	}
+
+	s := r.v.LastUsedAt.Format(time.RFC3339)
+	return &s
+}
@@ -0,0 +1,81 @@
+package query_resolvers
+
+import (
+	"context"
+
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	log "github.com/sirupsen/logrus"
+)
+
+type ChannelEmotesResolver struct {
+	ctx        context.Context
+	identifier string
+	v          *actions.ChannelEmotes
+
+	fields map[string]*SelectedField
+}
+
+// Get the emotes of several channels at once, in the order they were asked for. Unknown channels are omitted
+func (*QueryResolver) ChannelEmotes(ctx context.Context, args struct{ Channels []string }) ([]*ChannelEmotesResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesRead); err != nil {
+		return nil, err
+	}
+	if limit := actions.Emotes.GetChannelEmotesLimit(); len(args.Channels) > limit {
+		return nil, resolvers.ErrTooManyChannels(limit)
+	}
+
+	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	channels, err := actions.Emotes.GetChannelEmotes(ctx, args.Channels)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	result := []*ChannelEmotesResolver{}
+	seen := map[string]bool{}
+	for _, identifier := range args.Channels {
+		ce, ok := channels[identifier]
+		if !ok || seen[identifier] {
+			continue
+		}
+		seen[identifier] = true
+
+		result = append(result, &ChannelEmotesResolver{
+			ctx:        ctx,
+			identifier: identifier,
+			v:          ce,
+			fields:     field.Children,
+		})
+	}
+
+	return result, nil
+}
+
+func (r *ChannelEmotesResolver) Identifier() string {
+	return r.identifier
+}
+
+func (r *ChannelEmotesResolver) Channel() (*UserResolver, error) {
+	return GenerateUserResolver(r.ctx, r.v.Channel, &r.v.Channel.ID, r.fields["channel"].Children)
+}
+
+func (r *ChannelEmotesResolver) Emotes() ([]*EmoteResolver, error) {
+	result := []*EmoteResolver{}
+	for _, e := range r.v.Emotes {
+		resolver, err := GenerateEmoteResolver(r.ctx, e, nil, r.fields["emotes"].Children)
+		if err != nil {
+			log.WithError(err).Error("generation")
+			return nil, resolvers.ErrInternalServer
+		}
+		if resolver != nil {
+			result = append(result, resolver)
+		}
+	}
+	return result, nil
+}
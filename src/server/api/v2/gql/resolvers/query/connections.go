@@ -0,0 +1,350 @@
+package query_resolvers
+
+import (
+	"context"
+
+	"github.com/SevenTV/ServerGo/src/cache"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	"github.com/SevenTV/ServerGo/src/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Get the page size of a connection, or an error if it is above the maximum
+func getConnectionLimit(limit *int32, max int32) (int64, error) {
+	l := int32(20)
+	if limit != nil {
+		l = *limit
+	}
+	if l > max {
+		return 0, resolvers.ErrQueryLimit
+	}
+	if l < 1 {
+		l = 1
+	}
+
+	return int64(l), nil
+}
+
+// Decode the cursor of a connection's "after" argument
+func getConnectionCursor(after *string) (*actions.Cursor, error) {
+	if after == nil {
+		return nil, nil
+	}
+
+	c, ok := actions.DecodeCursor(*after)
+	if !ok {
+		return nil, resolvers.ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+func emoteSearchOptions(usr *datastructure.User, query string, channel *string, globalState *string, filter *EmoteSearchFilter) actions.EmoteSearchOptions {
+	opts := actions.EmoteSearchOptions{
+		Query:   query,
+		Channel: channel,
+		Actor:   usr,
+	}
+	if globalState != nil {
+		opts.GlobalState = *globalState
+	}
+	if filter != nil {
+		opts.Visibility = filter.Visibility
+		opts.VisibilityClear = filter.VisibilityClear
+		opts.WidthRange = filter.WidthRange
+	}
+
+	return opts
+}
+
+// Search for emotes, a page at a time
+func (*QueryResolver) SearchEmotesConnection(ctx context.Context, args struct {
+	Query       string
+	Limit       *int32
+	After       *string
+	GlobalState *string
+	SortBy      *string
+	SortOrder   *int32
+	Channel     *string
+	Filter      *EmoteSearchFilter
+}) (*emoteConnectionResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesRead); err != nil {
+		return nil, err
+	}
+
+	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	limit, err := getConnectionLimit(args.Limit, resolvers.QueryLimit)
+	if err != nil {
+		return nil, err
+	}
+	after, err := getConnectionCursor(args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	usr, _ := ctx.Value(utils.UserKey).(*datastructure.User)
+	opts := emoteSearchOptions(usr, args.Query, args.Channel, args.GlobalState, args.Filter)
+
+	// Get sorting direction, the same as search_emotes
+	var order int32 = 1
+	if args.SortOrder != nil {
+		order = *args.SortOrder
+	}
+	if order > 1 {
+		return nil, resolvers.ErrInvalidSortOrder
+	}
+	if order == 1 {
+		order = -1
+	} else if order == 0 {
+		order = 1
+	}
+	opts.SortDirection = order
+	if args.SortBy != nil && *args.SortBy == "popularity" {
+		opts.SortBy = "popularity"
+		opts.SortDirection = -order
+	}
+
+	emotes, page, err := actions.Emotes.Search(ctx, opts, limit, after)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	var nodeFields map[string]*SelectedField
+	if f, ok := field.Children["nodes"]; ok {
+		nodeFields = f.Children
+	}
+	nodes := make([]*EmoteResolver, len(emotes))
+	for i, e := range emotes {
+		if nodes[i], err = GenerateEmoteResolver(ctx, e, nil, nodeFields); err != nil {
+			return nil, err
+		}
+	}
+
+	return &emoteConnectionResolver{
+		nodes:      nodes,
+		pageInfo:   newPageInfoResolver(page),
+		totalCount: int32(page.TotalCount),
+	}, nil
+}
+
+type emoteConnectionResolver struct {
+	nodes      []*EmoteResolver
+	pageInfo   *pageInfoResolver
+	totalCount int32
+}
+
+func (r *emoteConnectionResolver) Nodes() []*EmoteResolver {
+	return r.nodes
+}
+
+func (r *emoteConnectionResolver) PageInfo() *pageInfoResolver {
+	return r.pageInfo
+}
+
+func (r *emoteConnectionResolver) TotalCount() int32 {
+	return r.totalCount
+}
+
+// Search for users, newest first, a page at a time
+func (*QueryResolver) SearchUsersConnection(ctx context.Context, args struct {
+	Query string
+	Limit *int32
+	After *string
+}) (*userConnectionResolver, error) {
+	usr, _ := ctx.Value(utils.UserKey).(*datastructure.User)
+	if usr == nil || !usr.HasPermission(datastructure.RolePermissionManageUsers) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	limit, err := getConnectionLimit(args.Limit, resolvers.QueryLimit)
+	if err != nil {
+		return nil, err
+	}
+	after, err := getConnectionCursor(args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{
+		"login": bson.M{
+			"$regex": actions.SearchPattern(args.Query),
+		},
+	}
+	total, err := cache.GetCollectionSize(ctx, "users", match)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	filter := match
+	if after != nil {
+		filter = bson.M{"$and": bson.A{match, after.Filter("_id", -1)}}
+	}
+
+	users := []*datastructure.User{}
+	cur, err := mongo.Collection(mongo.CollectionNameUsers).Find(ctx, filter, options.Find().
+		SetSort(bson.M{"_id": -1}).
+		SetLimit(limit+1),
+	)
+	if err == nil {
+		err = cur.All(ctx, &users)
+	}
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	page := actions.Page{TotalCount: total}
+	if int64(len(users)) > limit {
+		users = users[:limit]
+		page.HasNextPage = true
+	}
+	if len(users) > 0 {
+		page.EndCursor = EncodeCursor(users[len(users)-1].ID)
+	}
+
+	var nodeFields map[string]*SelectedField
+	if f, ok := field.Children["nodes"]; ok {
+		nodeFields = f.Children
+	}
+	nodes := make([]*UserResolver, len(users))
+	for i, u := range users {
+		if nodes[i], err = GenerateUserResolver(ctx, u, nil, nodeFields); err != nil {
+			return nil, err
+		}
+	}
+
+	return &userConnectionResolver{
+		nodes:      nodes,
+		pageInfo:   newPageInfoResolver(page),
+		totalCount: int32(page.TotalCount),
+	}, nil
+}
+
+type userConnectionResolver struct {
+	nodes      []*UserResolver
+	pageInfo   *pageInfoResolver
+	totalCount int32
+}
+
+func (r *userConnectionResolver) Nodes() []*UserResolver {
+	return r.nodes
+}
+
+func (r *userConnectionResolver) PageInfo() *pageInfoResolver {
+	return r.pageInfo
+}
+
+func (r *userConnectionResolver) TotalCount() int32 {
+	return r.totalCount
+}
+
+// Get audit logs, newest first, a page at a time
+func (*QueryResolver) AuditLogsConnection(ctx context.Context, args struct {
+	Limit *int32
+	After *string
+	Types *[]int32
+}) (*auditLogConnectionResolver, error) {
+	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	limit, err := getConnectionLimit(args.Limit, 250)
+	if err != nil {
+		return nil, err
+	}
+	after, err := getConnectionCursor(args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{}
+	if args.Types != nil && len(*args.Types) > 0 {
+		match["type"] = bson.M{
+			"$in": *args.Types,
+		}
+	}
+	total, err := cache.GetCollectionSize(ctx, "audit", match)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	filter := match
+	if after != nil {
+		filter = bson.M{"$and": bson.A{match, after.Filter("_id", -1)}}
+	}
+
+	logs := []*datastructure.AuditLog{}
+	cur, err := mongo.Collection(mongo.CollectionNameAudit).Find(ctx, filter, options.Find().
+		SetSort(bson.M{"_id": -1}).
+		SetLimit(limit+1),
+	)
+	if err == nil {
+		err = cur.All(ctx, &logs)
+	}
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	page := actions.Page{TotalCount: total}
+	if int64(len(logs)) > limit {
+		logs = logs[:limit]
+		page.HasNextPage = true
+	}
+	if len(logs) > 0 {
+		page.EndCursor = EncodeCursor(logs[len(logs)-1].ID)
+	}
+
+	var nodeFields map[string]*SelectedField
+	if f, ok := field.Children["nodes"]; ok {
+		nodeFields = f.Children
+	}
+	nodes := make([]*auditResolver, len(logs))
+	for i, l := range logs {
+		if nodes[i], err = GenerateAuditResolver(ctx, l, nodeFields); err != nil {
+			return nil, err
+		}
+	}
+
+	return &auditLogConnectionResolver{
+		nodes:      nodes,
+		pageInfo:   newPageInfoResolver(page),
+		totalCount: int32(page.TotalCount),
+	}, nil
+}
+
+type auditLogConnectionResolver struct {
+	nodes      []*auditResolver
+	pageInfo   *pageInfoResolver
+	totalCount int32
+}
+
+func (r *auditLogConnectionResolver) Nodes() []*auditResolver {
+	return r.nodes
+}
+
+func (r *auditLogConnectionResolver) PageInfo() *pageInfoResolver {
+	return r.pageInfo
+}
+
+func (r *auditLogConnectionResolver) TotalCount() int32 {
+	return r.totalCount
+}
@@ -0,0 +1,39 @@
+package query_resolvers
+
+import (
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Encode the ID of the last item of a page as an opaque cursor
+func EncodeCursor(id primitive.ObjectID) string {
+	return actions.Cursor{ID: id}.Encode()
+}
+
+// Decode a cursor made by EncodeCursor
+func DecodeCursor(cursor string) (primitive.ObjectID, bool) {
+	c, ok := actions.DecodeCursor(cursor)
+	return c.ID, ok
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func newPageInfoResolver(page actions.Page) *pageInfoResolver {
+	r := &pageInfoResolver{hasNextPage: page.HasNextPage}
+	if page.EndCursor != "" {
+		r.endCursor = &page.EndCursor
+	}
+
+	return r
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
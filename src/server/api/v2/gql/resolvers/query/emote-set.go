@@ -0,0 +1,84 @@
+package query_resolvers
+
+import (
+	"context"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/cache"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type EmoteSetResolver struct {
+	ctx context.Context
+	v   *datastructure.EmoteSet
+
+	fields map[string]*SelectedField
+}
+
+func GenerateEmoteSetResolver(ctx context.Context, set *datastructure.EmoteSet, fields map[string]*SelectedField) (*EmoteSetResolver, error) {
+	if _, ok := fields["emotes"]; ok && set.Emotes == nil {
+		set.Emotes = &[]*datastructure.Emote{}
+		if len(set.EmoteIDs) > 0 {
+			if err := cache.Find(ctx, "emotes", "", bson.M{
+				"_id": bson.M{
+					"$in": set.EmoteIDs,
+				},
+			}, set.Emotes); err != nil {
+				log.WithError(err).Error("mongo")
+				return nil, resolvers.ErrInternalServer
+			}
+		}
+	}
+
+	return &EmoteSetResolver{
+		ctx:    ctx,
+		v:      set,
+		fields: fields,
+	}, nil
+}
+
+func (r *EmoteSetResolver) ID() string {
+	return r.v.ID.Hex()
+}
+
+func (r *EmoteSetResolver) Name() string {
+	return r.v.Name
+}
+
+func (r *EmoteSetResolver) OwnerID() string {
+	return r.v.OwnerID.Hex()
+}
+
+func (r *EmoteSetResolver) EmoteIDs() []string {
+	ids := make([]string, len(r.v.EmoteIDs))
+	for i, id := range r.v.EmoteIDs {
+		ids[i] = id.Hex()
+	}
+	return ids
+}
+
+func (r *EmoteSetResolver) Emotes() ([]*EmoteResolver, error) {
+	if r.v.Emotes == nil {
+		return nil, nil
+	}
+
+	result := []*EmoteResolver{}
+	for _, e := range *r.v.Emotes {
+		resolver, err := GenerateEmoteResolver(r.ctx, e, nil, r.fields["emotes"].Children)
+		if err != nil {
+			log.WithError(err).Error("generation")
+			return nil, resolvers.ErrInternalServer
+		}
+		if resolver != nil {
+			result = append(result, resolver)
+		}
+	}
+	return result, nil
+}
+
+func (r *EmoteSetResolver) CreatedAt() string {
+	return r.v.CreatedAt.Format(time.RFC3339)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
 	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/loaders"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
 	"github.com/SevenTV/ServerGo/src/utils"
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,15 +28,30 @@ type EmoteResolver struct {
 
 func GenerateEmoteResolver(ctx context.Context, emote *datastructure.Emote, emoteID *primitive.ObjectID, fields map[string]*SelectedField) (*EmoteResolver, error) {
 	if emote == nil {
-		emote = &datastructure.Emote{}
-		if err := cache.FindOne(ctx, "emotes", "", bson.M{
-			"_id": emoteID,
-		}, emote); err != nil {
-			if err != mongo.ErrNoDocuments {
+		if l := loaders.For(ctx); l != nil && emoteID != nil {
+			// Batched with the other emotes looked up by this request
+			e, err := l.Emotes.Load(*emoteID)
+			if err != nil {
 				log.WithError(err).Error("mongo")
 				return nil, resolvers.ErrInternalServer
 			}
-			return nil, nil
+			if e == nil {
+				return nil, nil
+			}
+			// The resolver fills in relational data, so it gets its own copy
+			loaded := *e
+			emote = &loaded
+		} else {
+			emote = &datastructure.Emote{}
+			if err := cache.FindOne(ctx, "emotes", "", bson.M{
+				"_id": emoteID,
+			}, emote); err != nil {
+				if err != mongo.ErrNoDocuments {
+					log.WithError(err).Error("mongo")
+					return nil, resolvers.ErrInternalServer
+				}
+				return nil, nil
+			}
 		}
 	}
 
@@ -155,6 +171,13 @@ func (r *EmoteResolver) Status() int32 {
 	return r.v.Status
 }
 
+func (r *EmoteResolver) StatusMessage() *string {
+	if r.v.StatusMessage == "" {
+		return nil
+	}
+	return &r.v.StatusMessage
+}
+
 func (r *EmoteResolver) Tags() []string {
 	return r.v.Tags
 }
@@ -317,11 +340,54 @@ func (r *EmoteResolver) Channels(ctx context.Context, args struct {
 	return &users, nil
 }
 
+// Get the channels the emote is added to, highest role first, a page at a time
+func (r *EmoteResolver) ChannelsConnection(ctx context.Context, args struct {
+	Limit *int32
+	After *string
+}) (*userConnectionResolver, error) {
+	limit, err := getConnectionLimit(args.Limit, 250)
+	if err != nil {
+		return nil, err
+	}
+	after, err := getConnectionCursor(args.After)
+	if err != nil {
+		return nil, err
+	}
+
+	users, page, err := actions.Emotes.GetChannels(ctx, r.v.ID, limit, after)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	var nodeFields map[string]*SelectedField
+	if f, ok := r.fields["channels_connection"]; ok {
+		if f, ok := f.Children["nodes"]; ok {
+			nodeFields = f.Children
+		}
+	}
+	nodes := []*UserResolver{}
+	for _, u := range users {
+		resolver, err := GenerateUserResolver(r.ctx, u, &u.ID, nodeFields)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, resolver)
+	}
+
+	return &userConnectionResolver{
+		nodes:      nodes,
+		pageInfo:   newPageInfoResolver(page),
+		totalCount: int32(page.TotalCount),
+	}, nil
+}
+
 func (r *EmoteResolver) ChannelCount() int32 {
 	return *r.v.ChannelCount
 }
 
-func (r *EmoteResolver) Reports() (*[]*reportResolver, error) {
+func (r *EmoteResolver) Reports() (*[]*ReportResolver, error) {
 	u, ok := r.ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok || (u.Rank != datastructure.UserRankAdmin && u.Rank != datastructure.UserRankModerator) {
 		return nil, resolvers.ErrAccessDenied
@@ -332,7 +398,7 @@ func (r *EmoteResolver) Reports() (*[]*reportResolver, error) {
 	}
 
 	e := *r.v.Reports
-	reports := make([]*reportResolver, len(e))
+	reports := make([]*ReportResolver, len(e))
 	var err error
 	for i, l := range e {
 		reports[i], err = GenerateReportResolver(r.ctx, l, r.fields["reports"].Children)
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"math"
-	"regexp"
 	"strings"
 
 	"github.com/SevenTV/ServerGo/src/cache"
@@ -12,6 +11,7 @@ import (
 	mongocache "github.com/SevenTV/ServerGo/src/mongo/cache"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
 	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
 	api_proxy "github.com/SevenTV/ServerGo/src/server/api/v2/proxy"
 	"github.com/SevenTV/ServerGo/src/utils"
@@ -29,8 +29,6 @@ type QueryResolver struct{}
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
 
-var searchRegex = regexp.MustCompile(`[.*+?^${}()|[\\]\\\\]`)
-
 type SelectedField struct {
 	Name     string
 	Children map[string]*SelectedField
@@ -180,6 +178,10 @@ func (*QueryResolver) Role(ctx context.Context, args struct{ ID string }) (*Role
 }
 
 func (*QueryResolver) Emote(ctx context.Context, args struct{ ID string }) (*EmoteResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesRead); err != nil {
+		return nil, err
+	}
+
 	id, err := primitive.ObjectIDFromHex(args.ID)
 	if err != nil {
 		return nil, nil
@@ -207,6 +209,10 @@ func (*QueryResolver) Emote(ctx context.Context, args struct{ ID string }) (*Emo
 }
 
 func (*QueryResolver) Emotes(ctx context.Context, args struct{ List []string }) (*[]*EmoteResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesRead); err != nil {
+		return nil, err
+	}
+
 	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
 	if failed {
 		return nil, resolvers.ErrDepth
@@ -247,6 +253,10 @@ func (*QueryResolver) SearchEmotes(ctx context.Context, args struct {
 	SubmittedBy *string
 	Filter      *EmoteSearchFilter
 }) ([]*EmoteResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesRead); err != nil {
+		return nil, err
+	}
+
 	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
 	if failed {
 		return nil, resolvers.ErrDepth
@@ -262,73 +272,15 @@ func (*QueryResolver) SearchEmotes(ctx context.Context, args struct {
 		return nil, resolvers.ErrQueryLimit
 	}
 
-	// Get the query parameter, used to search for specific emote names or tags
-	query := strings.Trim(args.Query, " ")
-	hasQuery := len(query) > 0
-	lQuery := fmt.Sprintf("(?i)%s", strings.ToLower(searchRegex.ReplaceAllString(query, "\\\\$0")))
-
 	// Get actor user
 	usr, _ := ctx.Value(utils.UserKey).(*datastructure.User)
 
 	// Create aggregation
 	opts := options.Aggregate()
 	emotes := []*datastructure.Emote{}
-	match := bson.M{
-		"status": datastructure.EmoteStatusLive,
-	}
-	if args.Channel != nil {
-		var targetChannel *datastructure.User
-		// Find user and get their emotes
-		if err := cache.FindOne(ctx, "users", "", bson.M{"login": args.Channel}, &targetChannel); err == nil {
-			match["_id"] = bson.M{"$in": targetChannel.EmoteIDs}
-		}
-	}
-
-	if usr == nil || !usr.HasPermission(datastructure.RolePermissionEmoteEditAll) {
-		var usrID primitive.ObjectID
-		if usr != nil {
-			usrID = usr.ID
-		}
-
-		match["$and"] = bson.A{
-			bson.M{"$or": bson.A{
-				bson.M{"visibility": bson.M{"$bitsAllClear": int32(datastructure.EmoteVisibilityPrivate | datastructure.EmoteVisibilityUnlisted)}},
-				bson.M{"owner": usrID},
-			}},
-		}
-	}
-
-	if args.Filter != nil {
-		// Handle visibility filter
-		visibilityFilter := bson.M{}
-		if args.Filter.Visibility != nil {
-			visibilityFilter["$bitsAllSet"] = *args.Filter.Visibility
-		}
-		if args.Filter.VisibilityClear != nil {
-			visibilityFilter["$bitsAllClear"] = *args.Filter.VisibilityClear
-		}
-		if len(visibilityFilter) > 0 {
-			match["visibility"] = visibilityFilter
-		}
-
-		// Handle width range filter
-		if args.Filter.WidthRange != nil {
-			if len(*args.Filter.WidthRange) != 2 { // Error if the length wasn't 2
-				return nil, fmt.Errorf("filter.width_range must be a list with 2 integers, but the length given was %d", len(*args.Filter.WidthRange))
-			}
-
-			list := *args.Filter.WidthRange
-			min := list[0]
-			max := list[1]
-			if max < min {
-				return nil, fmt.Errorf("the max value cannot be smaller than the minimum value")
-			}
-
-			match["width.3"] = bson.M{
-				"$gte": min,
-				"$lte": max,
-			}
-		}
+	match, err := actions.Emotes.SearchFilter(ctx, emoteSearchOptions(usr, args.Query, args.Channel, args.GlobalState, args.Filter))
+	if err != nil {
+		return nil, err
 	}
 
 	// Pagination
@@ -416,33 +368,6 @@ func (*QueryResolver) SearchEmotes(ctx context.Context, args struct {
 		}
 	}
 
-	// If a query is specified, add sorting
-	if hasQuery {
-		match["$or"] = bson.A{
-			bson.M{
-				"name": bson.M{
-					"$regex": lQuery,
-				},
-			},
-			bson.M{
-				"tags": bson.M{
-					"$regex": lQuery,
-				},
-			},
-		}
-	}
-
-	// If global state is specified, filter global emotes
-	if args.GlobalState != nil {
-		globalState := *args.GlobalState
-		switch globalState {
-		case "only": // Only: query only global emotes
-			match["visibility"] = bson.M{"$bitsAllSet": int32(datastructure.EmoteVisibilityGlobal)}
-		case "hide": // Hide: omit global emotes from query
-			match["visibility"] = bson.M{"$bitsAllClear": int32(datastructure.EmoteVisibilityGlobal)}
-		}
-	}
-
 	// Determine the full collection size
 	f := ctx.Value(utils.RequestCtxKey).(*fiber.Ctx) // Fiber context
 
@@ -487,6 +412,10 @@ func (*QueryResolver) ThirdPartyEmotes(ctx context.Context, args struct {
 	Channel   string
 	Global    *bool
 }) (*[]*EmoteResolver, error) {
+	if err := resolvers.RequireScope(ctx, datastructure.AccessTokenScopeEmotesRead); err != nil {
+		return nil, err
+	}
+
 	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
 	if failed {
 		return nil, resolvers.ErrDepth
@@ -569,8 +498,7 @@ func (*QueryResolver) SearchUsers(ctx context.Context, args struct {
 		page = int64(*args.Page)
 	}
 
-	query := strings.Trim(args.Query, " ")
-	lQuery := fmt.Sprintf("(?i)%s", strings.ToLower(searchRegex.ReplaceAllString(query, "\\\\$0")))
+	lQuery := actions.SearchPattern(args.Query)
 
 	opts := options.Find().SetSort(bson.M{
 		"_id": -1,
@@ -646,7 +574,7 @@ func (*QueryResolver) Meta(ctx context.Context) (*datastructure.Meta, error) {
 		log.WithError(err).Error("redis")
 	}
 
-	cachedRoles := mongocache.CachedRoles.([]datastructure.Role)
+	cachedRoles, _ := mongocache.GetRoles().([]datastructure.Role)
 	roles := []string{}
 	for _, r := range cachedRoles {
 		b, err := json.Marshal(r)
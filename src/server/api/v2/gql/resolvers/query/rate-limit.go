@@ -0,0 +1,123 @@
+package query_resolvers
+
+import (
+	"context"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/ratelimit"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	"github.com/SevenTV/ServerGo/src/utils"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Get how much of each rate limit a caller has used, identified by their access token, user ID or IP address. Requires permission
+func (*QueryResolver) RateLimits(ctx context.Context, args struct {
+	UserID  *string
+	TokenID *string
+	IP      *string
+}) ([]*rateLimitConsumptionResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, _ := ctx.Value(utils.UserKey).(*datastructure.User)
+	if usr == nil || !usr.HasPermission(datastructure.RolePermissionManageUsers) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	caller := ratelimit.Caller{}
+	userID := args.UserID
+	switch {
+	case args.TokenID != nil:
+		id, err := primitive.ObjectIDFromHex(*args.TokenID)
+		if err != nil {
+			return nil, resolvers.ErrUnknownAccessToken
+		}
+		token, err := actions.AccessTokens.Get(ctx, id)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, resolvers.ErrUnknownAccessToken
+			}
+			log.WithError(err).Error("mongo")
+			return nil, resolvers.ErrInternalServer
+		}
+
+		// Requests made with the token count against its user
+		caller.TokenID = token.ID.Hex()
+		tokenUserID := token.UserID.Hex()
+		userID = &tokenUserID
+	case args.UserID != nil:
+	case args.IP != nil:
+		caller.Identifier = *args.IP
+	default:
+		return nil, resolvers.ErrMissingCaller
+	}
+
+	// The role of the user decides their tier
+	if userID != nil {
+		id, err := primitive.ObjectIDFromHex(*userID)
+		if err != nil {
+			return nil, resolvers.ErrUnknownUser
+		}
+		ub, err := actions.Users.GetByID(ctx, id)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, resolvers.ErrUnknownUser
+			}
+			log.WithError(err).Error("mongo")
+			return nil, resolvers.ErrInternalServer
+		}
+
+		caller.RoleID = ub.User.Role.ID.Hex()
+		if caller.Identifier == "" {
+			caller.Identifier = ub.User.ID.Hex()
+		}
+	}
+
+	consumption, err := ratelimit.GetConsumption(ctx, caller)
+	if err != nil {
+		log.WithError(err).Error("redis")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	result := make([]*rateLimitConsumptionResolver, len(consumption))
+	for i, c := range consumption {
+		result[i] = &rateLimitConsumptionResolver{c}
+	}
+	return result, nil
+}
+
+type rateLimitConsumptionResolver struct {
+	v ratelimit.Consumption
+}
+
+func (r *rateLimitConsumptionResolver) Policy() string {
+	return r.v.Policy
+}
+
+func (r *rateLimitConsumptionResolver) Tier() string {
+	return r.v.Tier
+}
+
+func (r *rateLimitConsumptionResolver) Window() string {
+	return r.v.Window
+}
+
+func (r *rateLimitConsumptionResolver) Limit() int32 {
+	return r.v.Limit
+}
+
+func (r *rateLimitConsumptionResolver) Used() int32 {
+	return r.v.Used
+}
+
+func (r *rateLimitConsumptionResolver) Remaining() int32 {
+	return r.v.Remaining
+}
+
+func (r *rateLimitConsumptionResolver) ResetIn() int32 {
+	return int32(r.v.Reset)
+}
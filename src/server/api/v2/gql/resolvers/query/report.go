@@ -2,26 +2,193 @@ package query_resolvers
 
 import (
 	"context"
+	"time"
 
+	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	"github.com/SevenTV/ServerGo/src/utils"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type reportResolver struct {
+// List reports for moderators, newest first
+func (*QueryResolver) Reports(ctx context.Context, args struct {
+	TargetType    *string
+	Cleared       *bool
+	AssigneeID    *string
+	CreatedAfter  *string
+	CreatedBefore *string
+	Limit         *int32
+	After         *string
+}) (*reportConnectionResolver, error) {
+	if err := resolvers.RequireSession(ctx); err != nil {
+		return nil, err
+	}
+
+	usr, _ := ctx.Value(utils.UserKey).(*datastructure.User)
+	if usr == nil || !usr.HasPermission(datastructure.RolePermissionManageReports) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	limit := int32(20)
+	if args.Limit != nil {
+		limit = *args.Limit
+	}
+	if limit > resolvers.QueryLimit {
+		return nil, resolvers.ErrQueryLimit
+	}
+	if limit < 1 {
+		limit = 1
+	}
+
+	filter := bson.M{}
+	if args.TargetType != nil {
+		filter["target.type"] = *args.TargetType
+	}
+	if args.Cleared != nil {
+		filter["cleared"] = *args.Cleared
+	}
+	if args.AssigneeID != nil {
+		if *args.AssigneeID == "" { // Unassigned reports
+			filter["assignee_id"] = nil
+		} else {
+			id, err := primitive.ObjectIDFromHex(*args.AssigneeID)
+			if err != nil {
+				return nil, resolvers.ErrUnknownUser
+			}
+			filter["assignee_id"] = id
+		}
+	}
+
+	// Reports are dated by their ID
+	idRange := bson.M{}
+	if args.CreatedAfter != nil {
+		t, err := time.Parse(time.RFC3339, *args.CreatedAfter)
+		if err != nil {
+			return nil, resolvers.ErrInvalidUpdate
+		}
+		idRange["$gte"] = primitive.NewObjectIDFromTimestamp(t)
+	}
+	if args.CreatedBefore != nil {
+		t, err := time.Parse(time.RFC3339, *args.CreatedBefore)
+		if err != nil {
+			return nil, resolvers.ErrInvalidUpdate
+		}
+		idRange["$lt"] = primitive.NewObjectIDFromTimestamp(t)
+	}
+	if len(idRange) > 0 {
+		filter["_id"] = idRange
+	}
+
+	total, err := mongo.Collection(mongo.CollectionNameReports).CountDocuments(ctx, filter)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	// Continue after the cursor, which is the last report of the previous page
+	if args.After != nil {
+		after, ok := DecodeCursor(*args.After)
+		if !ok {
+			return nil, resolvers.ErrInvalidCursor
+		}
+		if before, ok := idRange["$lt"].(primitive.ObjectID); !ok || after.Hex() < before.Hex() {
+			idRange["$lt"] = after
+		}
+		filter["_id"] = idRange
+	}
+
+	reports := []*datastructure.Report{}
+	cur, err := mongo.Collection(mongo.CollectionNameReports).Find(ctx, filter, options.Find().
+		SetSort(bson.M{"_id": -1}).
+		SetLimit(int64(limit)+1),
+	)
+	if err == nil {
+		err = cur.All(ctx, &reports)
+	}
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	pageInfo := &pageInfoResolver{}
+	if len(reports) > int(limit) {
+		reports = reports[:limit]
+		pageInfo.hasNextPage = true
+	}
+	if len(reports) > 0 {
+		cursor := EncodeCursor(reports[len(reports)-1].ID)
+		pageInfo.endCursor = &cursor
+	}
+
+	var nodeFields map[string]*SelectedField
+	if f, ok := field.Children["nodes"]; ok {
+		nodeFields = f.Children
+	}
+	nodes := make([]*ReportResolver, len(reports))
+	for i, r := range reports {
+		if nodes[i], err = GenerateReportResolver(ctx, r, nodeFields); err != nil {
+			return nil, err
+		}
+	}
+
+	return &reportConnectionResolver{
+		nodes:      nodes,
+		pageInfo:   pageInfo,
+		totalCount: int32(total),
+	}, nil
+}
+
+type reportConnectionResolver struct {
+	nodes      []*ReportResolver
+	pageInfo   *pageInfoResolver
+	totalCount int32
+}
+
+func (r *reportConnectionResolver) Nodes() []*ReportResolver {
+	return r.nodes
+}
+
+func (r *reportConnectionResolver) PageInfo() *pageInfoResolver {
+	return r.pageInfo
+}
+
+func (r *reportConnectionResolver) TotalCount() int32 {
+	return r.totalCount
+}
+
+type ReportResolver struct {
 	ctx context.Context
 	v   *datastructure.Report
 
 	fields map[string]*SelectedField
 }
 
-func GenerateReportResolver(ctx context.Context, report *datastructure.Report, fields map[string]*SelectedField) (*reportResolver, error) {
-	return &reportResolver{
+func GenerateReportResolver(ctx context.Context, report *datastructure.Report, fields map[string]*SelectedField) (*ReportResolver, error) {
+	return &ReportResolver{
 		ctx:    ctx,
 		v:      report,
 		fields: fields,
 	}, nil
 }
 
-func (r *reportResolver) ReporterID() *string {
+func (r *ReportResolver) ID() string {
+	return r.v.ID.Hex()
+}
+
+func (r *ReportResolver) CreatedAt() string {
+	return r.v.ID.Timestamp().Format(time.RFC3339)
+}
+
+func (r *ReportResolver) ReporterID() *string {
 	if r.v.ReporterID == nil {
 		return nil
 	}
@@ -29,7 +196,7 @@ func (r *reportResolver) ReporterID() *string {
 	return &hex
 }
 
-func (r *reportResolver) TargetID() *string {
+func (r *ReportResolver) TargetID() *string {
 	if r.v.Target.ID == nil {
 		return nil
 	}
@@ -37,40 +204,40 @@ func (r *reportResolver) TargetID() *string {
 	return &hex
 }
 
-func (r *reportResolver) TargetType() string {
+func (r *ReportResolver) TargetType() string {
 	return r.v.Target.Type
 }
 
-func (r *reportResolver) Reason() string {
+func (r *ReportResolver) Reason() string {
 	return r.v.Reason
 }
 
-func (r *reportResolver) Cleared() bool {
+func (r *ReportResolver) Cleared() bool {
 	return r.v.Cleared
 }
 
-func (r *reportResolver) UTarget() (*UserResolver, error) {
+func (r *ReportResolver) UTarget() (*UserResolver, error) {
 	if r.v.Target.Type == "users" {
 		return GenerateUserResolver(r.ctx, r.v.UTarget, r.v.Target.ID, r.fields["u_target"].Children)
 	}
 	return nil, nil
 }
 
-func (r *reportResolver) ETarget() (*EmoteResolver, error) {
+func (r *ReportResolver) ETarget() (*EmoteResolver, error) {
 	if r.v.Target.Type == "emotes" {
 		return GenerateEmoteResolver(r.ctx, r.v.ETarget, r.v.Target.ID, r.fields["e_target"].Children)
 	}
 	return nil, nil
 }
 
-func (r *reportResolver) Reporter() (*UserResolver, error) {
+func (r *ReportResolver) Reporter() (*UserResolver, error) {
 	if r.v.ReporterID != nil {
 		return GenerateUserResolver(r.ctx, r.v.Reporter, r.v.ReporterID, r.fields["reporter"].Children)
 	}
 	return nil, nil
 }
 
-func (r *reportResolver) AuditEntries() ([]string, error) {
+func (r *ReportResolver) AuditEntries() ([]string, error) {
 	if r.v.AuditEntries == nil {
 		return nil, nil
 	}
@@ -85,3 +252,102 @@ func (r *reportResolver) AuditEntries() ([]string, error) {
 	}
 	return logs, nil
 }
+
+func (r *ReportResolver) AssigneeID() *string {
+	if r.v.AssigneeID == nil {
+		return nil
+	}
+	hex := r.v.AssigneeID.Hex()
+	return &hex
+}
+
+func (r *ReportResolver) Assignee() (*UserResolver, error) {
+	if r.v.AssigneeID != nil {
+		return GenerateUserResolver(r.ctx, r.v.Assignee, r.v.AssigneeID, r.fields["assignee"].Children)
+	}
+	return nil, nil
+}
+
+func (r *ReportResolver) Notes() []*reportNoteResolver {
+	notes := make([]*reportNoteResolver, len(r.v.Notes))
+	for i, n := range r.v.Notes {
+		notes[i] = &reportNoteResolver{r.ctx, n, r.fields["notes"].Children}
+	}
+	return notes
+}
+
+func (r *ReportResolver) Resolution() *reportResolutionResolver {
+	if r.v.Resolution == nil {
+		return nil
+	}
+	return &reportResolutionResolver{r.ctx, r.v.Resolution, r.fields["resolution"].Children}
+}
+
+type reportNoteResolver struct {
+	ctx context.Context
+	v   *datastructure.ReportNote
+
+	fields map[string]*SelectedField
+}
+
+func (r *reportNoteResolver) AuthorID() string {
+	return r.v.AuthorID.Hex()
+}
+
+func (r *reportNoteResolver) Author() (*UserResolver, error) {
+	return GenerateUserResolver(r.ctx, nil, &r.v.AuthorID, r.fields["author"].Children)
+}
+
+func (r *reportNoteResolver) Content() string {
+	return r.v.Content
+}
+
+func (r *reportNoteResolver) CreatedAt() string {
+	return r.v.CreatedAt.Format(time.RFC3339)
+}
+
+type reportResolutionResolver struct {
+	ctx context.Context
+	v   *datastructure.ReportResolution
+
+	fields map[string]*SelectedField
+}
+
+func (r *reportResolutionResolver) Outcome() string {
+	return string(r.v.Outcome)
+}
+
+func (r *reportResolutionResolver) ResolvedByID() string {
+	return r.v.ResolvedBy.Hex()
+}
+
+func (r *reportResolutionResolver) ResolvedAt() string {
+	return r.v.ResolvedAt.Format(time.RFC3339)
+}
+
+func (r *reportResolutionResolver) AuditLogID() *string {
+	if r.v.AuditLogID == nil {
+		return nil
+	}
+	hex := r.v.AuditLogID.Hex()
+	return &hex
+}
+
+func (r *reportResolutionResolver) AuditLog() (*auditResolver, error) {
+	if r.v.AuditLogID == nil {
+		return nil, nil
+	}
+
+	l := &datastructure.AuditLog{}
+	if err := mongo.Collection(mongo.CollectionNameAudit).FindOne(r.ctx, bson.M{
+		"_id": r.v.AuditLogID,
+	}).Decode(l); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	return GenerateAuditResolver(r.ctx, l, r.fields["audit_log"].Children)
+}
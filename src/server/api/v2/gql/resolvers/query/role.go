@@ -5,7 +5,11 @@ import (
 	"fmt"
 
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/loaders"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type RoleResolver struct {
@@ -27,7 +31,20 @@ func GenerateRoleResolver(ctx context.Context, pRole *datastructure.Role, roleID
 		return nil, nil
 	}
 
-	role := datastructure.GetRole(roleID)
+	var role datastructure.Role
+	if l := loaders.For(ctx); l != nil {
+		r, err := l.Roles.Load(*roleID)
+		if err != nil {
+			log.WithError(err).Error("mongo")
+			return nil, resolvers.ErrInternalServer
+		}
+		if r == nil {
+			r = datastructure.DefaultRole
+		}
+		role = *r
+	} else {
+		role = datastructure.GetRole(roleID)
+	}
 	r := &RoleResolver{
 		ctx:    ctx,
 		v:      &role,
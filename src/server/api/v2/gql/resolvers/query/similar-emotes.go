@@ -0,0 +1,104 @@
+package query_resolvers
+
+import (
+	"context"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
+	"github.com/SevenTV/ServerGo/src/utils"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (*QueryResolver) SimilarEmotes(ctx context.Context, args struct {
+	ID    string
+	Limit *int32
+}) ([]*EmoteResolver, error) {
+	usr, _ := ctx.Value(utils.UserKey).(*datastructure.User)
+	if usr == nil || !usr.HasPermission(datastructure.RolePermissionEmoteEditAll) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	limit := int32(20)
+	if args.Limit != nil {
+		limit = *args.Limit
+	}
+	if limit > resolvers.QueryLimit {
+		return nil, resolvers.ErrQueryLimit
+	}
+
+	id, err := primitive.ObjectIDFromHex(args.ID)
+	if err != nil {
+		return nil, resolvers.ErrUnknownEmote
+	}
+	hashes, err := actions.Emotes.GetPHash(ctx, id)
+	if err == mongo.ErrNoDocuments {
+		return nil, resolvers.ErrUnknownEmote
+	}
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	similar, err := actions.Emotes.FindSimilarEmotes(ctx, hashes, actions.SimilarEmoteMaxDistance(), int(limit), &id)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	result := make([]*EmoteResolver, len(similar))
+	for i, s := range similar {
+		if result[i], err = GenerateEmoteResolver(ctx, s.Emote, nil, field.Children); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (*QueryResolver) DuplicateEmotes(ctx context.Context, args struct {
+	Limit *int32
+}) ([][]*EmoteResolver, error) {
+	usr, _ := ctx.Value(utils.UserKey).(*datastructure.User)
+	if usr == nil || !usr.HasPermission(datastructure.RolePermissionEmoteEditAll) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	field, failed := GenerateSelectedFieldMap(ctx, resolvers.MaxDepth)
+	if failed {
+		return nil, resolvers.ErrDepth
+	}
+
+	limit := int32(20)
+	if args.Limit != nil {
+		limit = *args.Limit
+	}
+	if limit > resolvers.QueryLimit {
+		return nil, resolvers.ErrQueryLimit
+	}
+
+	groups, err := actions.Emotes.FindDuplicateEmotes(ctx, actions.SimilarEmoteMaxDistance(), int(limit))
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	result := make([][]*EmoteResolver, len(groups))
+	for i, g := range groups {
+		result[i] = make([]*EmoteResolver, len(g))
+		for j, e := range g {
+			if result[i][j], err = GenerateEmoteResolver(ctx, e, nil, field.Children); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return result, nil
+}
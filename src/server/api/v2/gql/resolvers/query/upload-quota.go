@@ -0,0 +1,44 @@
+package query_resolvers
+
+import (
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+)
+
+type uploadQuotaResolver struct {
+	v *actions.UploadQuota
+}
+
+func (r *uploadQuotaResolver) DailyLimit() int32 {
+	return r.v.DailyLimit
+}
+
+func (r *uploadQuotaResolver) DailyUsed() int32 {
+	return r.v.DailyUsed
+}
+
+func (r *uploadQuotaResolver) DailyRemaining() int32 {
+	return r.v.DailyRemaining()
+}
+
+func (r *uploadQuotaResolver) DailyResetAt() *string {
+	if r.v.DailyResetAt.IsZero() {
+		return nil
+	}
+
+	s := r.v.DailyResetAt.UTC().Format(time.RFC3339)
+	return &s
+}
+
+func (r *uploadQuotaResolver) OwnedLimit() int32 {
+	return r.v.OwnedLimit
+}
+
+func (r *uploadQuotaResolver) Owned() int32 {
+	return r.v.Owned
+}
+
+func (r *uploadQuotaResolver) OwnedRemaining() int32 {
+	return r.v.OwnedRemaining()
+}
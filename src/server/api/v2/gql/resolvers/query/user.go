@@ -9,6 +9,7 @@ import (
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
 	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/loaders"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/gql/resolvers"
 	api_proxy "github.com/SevenTV/ServerGo/src/server/api/v2/proxy"
 	"github.com/SevenTV/ServerGo/src/utils"
@@ -30,15 +31,29 @@ type UserResolver struct {
 
 func GenerateUserResolver(ctx context.Context, user *datastructure.User, userID *primitive.ObjectID, fields map[string]*SelectedField) (*UserResolver, error) {
 	if user == nil || user.Login == "" {
-		user = &datastructure.User{}
-		if err := cache.FindOne(ctx, "users", "", bson.M{
-			"_id": userID,
-		}, user); err != nil {
-			if err != mongo.ErrNoDocuments {
+		if l := loaders.For(ctx); l != nil && userID != nil {
+			// Batched with the other users looked up by this request
+			u, err := l.Users.Load(*userID)
+			if err != nil {
 				log.WithError(err).Error("mongo")
 				return nil, resolvers.ErrInternalServer
 			}
-			return nil, nil
+			if u == nil {
+				return nil, nil
+			}
+			loaded := *u
+			user = &loaded
+		} else {
+			user = &datastructure.User{}
+			if err := cache.FindOne(ctx, "users", "", bson.M{
+				"_id": userID,
+			}, user); err != nil {
+				if err != mongo.ErrNoDocuments {
+					log.WithError(err).Error("mongo")
+					return nil, resolvers.ErrInternalServer
+				}
+				return nil, nil
+			}
 		}
 	}
 
@@ -106,13 +121,19 @@ func GenerateUserResolver(ctx context.Context, user *datastructure.User, userID
 	}
 
 	if v, ok := fields["emotes"]; ok && user.Emotes == nil {
-		if len(user.EmoteIDs) == 0 {
+		emoteIDs, err := actions.EmoteSets.GetActiveEmoteIDs(ctx, user)
+		if err != nil {
+			log.WithError(err).Error("mongo")
+			return nil, resolvers.ErrInternalServer
+		}
+
+		if len(emoteIDs) == 0 {
 			user.Emotes = &[]*datastructure.Emote{}
 		} else {
 			user.Emotes = &[]*datastructure.Emote{}
 			if err := cache.Find(ctx, "emotes", fmt.Sprintf("user:%s:emotes", user.ID.Hex()), bson.M{
 				"_id": bson.M{
-					"$in": user.EmoteIDs,
+					"$in": emoteIDs,
 				},
 			}, user.Emotes); err != nil {
 				log.WithError(err).Error("mongo")
@@ -541,7 +562,7 @@ func (r *UserResolver) ProfileImageURL() string {
 	}
 }
 
-func (r *UserResolver) Reports() (*[]*reportResolver, error) {
+func (r *UserResolver) Reports() (*[]*ReportResolver, error) {
 	u, ok := r.ctx.Value(utils.UserKey).(*datastructure.User)
 	if !ok || (u.Rank != datastructure.UserRankAdmin && u.Rank != datastructure.UserRankModerator) {
 		return nil, resolvers.ErrAccessDenied
@@ -552,7 +573,7 @@ func (r *UserResolver) Reports() (*[]*reportResolver, error) {
 	}
 
 	e := *r.v.Reports
-	reports := make([]*reportResolver, len(e))
+	reports := make([]*ReportResolver, len(e))
 	var err error
 	for i, l := range e {
 		reports[i], err = GenerateReportResolver(r.ctx, l, r.fields["reports"].Children)
@@ -639,6 +660,53 @@ func (r *UserResolver) EmoteSlots() int32 {
 	return r.v.GetEmoteSlots()
 }
 
+func (r *UserResolver) UploadQuota() (*uploadQuotaResolver, error) {
+	u, ok := r.ctx.Value(utils.UserKey).(*datastructure.User)
+	if !ok {
+		return nil, resolvers.ErrAccessDenied
+	}
+	if u.ID != r.v.ID && !u.HasPermission(datastructure.RolePermissionManageUsers) && !utils.ContainsObjectID(r.v.EditorIDs, u.ID) {
+		return nil, resolvers.ErrAccessDenied
+	}
+
+	quota, err := actions.Emotes.GetUploadQuota(r.ctx, r.v)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	return &uploadQuotaResolver{quota}, nil
+}
+
+func (r *UserResolver) EmoteSets() ([]*EmoteSetResolver, error) {
+	if r.ub.IsBanned() { // Omit if user is banned
+		return []*EmoteSetResolver{}, nil
+	}
+
+	sets, err := actions.EmoteSets.GetByOwner(r.ctx, r.v.ID)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return nil, resolvers.ErrInternalServer
+	}
+
+	result := make([]*EmoteSetResolver, len(sets))
+	for i, set := range sets {
+		if result[i], err = GenerateEmoteSetResolver(r.ctx, set, r.fields["emote_sets"].Children); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r *UserResolver) ActiveEmoteSetID() *string {
+	if r.v.ActiveEmoteSetID == nil {
+		return nil
+	}
+
+	id := r.v.ActiveEmoteSetID.Hex()
+	return &id
+}
+
 // Get user's folloer count
 func (r *UserResolver) FollowerCount() int32 {
 	count, err := api_proxy.GetTwitchFollowerCount(r.ctx, r.v.TwitchID)
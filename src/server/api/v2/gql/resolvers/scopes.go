@@ -0,0 +1,27 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/utils"
+)
+
+// Ensure the request, if made with a personal access token, was granted a scope.
+// Requests made with a session token hold every scope
+func RequireScope(ctx context.Context, scope datastructure.AccessTokenScope) error {
+	if token, ok := ctx.Value(utils.AccessTokenKey).(*datastructure.AccessToken); ok && !token.HasScope(scope) {
+		return ErrMissingScope(scope)
+	}
+
+	return nil
+}
+
+// Ensure the request was not made with a personal access token
+func RequireSession(ctx context.Context) error {
+	if _, ok := ctx.Value(utils.AccessTokenKey).(*datastructure.AccessToken); ok {
+		return ErrSessionRequired
+	}
+
+	return nil
+}
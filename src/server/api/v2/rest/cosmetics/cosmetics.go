@@ -2,6 +2,7 @@ package cosmetics
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/SevenTV/ServerGo/src/cache"
 	"github.com/SevenTV/ServerGo/src/mongo"
@@ -22,99 +23,103 @@ import (
 func GetBadges(router fiber.Router) {
 	Avatar(router)
 
-	router.Get("/", func(c *fiber.Ctx) error {
-		ctx := c.Context()
-		c.Set("Cache-Control", "max-age=300")
+	router.Get("/", GetBadgesHandler)
+}
 
-		idType := c.Query("user_identifier")
+// Get all badges and the users who can show them
+func GetBadgesHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	c.Set("Cache-Control", "max-age=300")
 
-		if !utils.Contains([]string{"object_id", "twitch_id", "login"}, idType) {
-			return restutil.ErrMissingQueryParams().Send(c, `user_identifier: must be 'object_id', 'twitch_id' or 'login'`)
-		}
+	idType := c.Query("user_identifier")
+
+	if !utils.Contains([]string{"object_id", "twitch_id", "login"}, idType) {
+		return restutil.ErrMissingQueryParams().Send(c, `user_identifier: must be 'object_id', 'twitch_id' or 'login'`)
+	}
+
+	// Retrieve all badges from the DB
+	var badges []*datastructure.Badge
+	if err := cache.Find(c.Context(), "badges", "", bson.M{}, &badges); err != nil {
+		log.WithError(err).Error("mongo")
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
 
-		// Retrieve all badges from the DB
-		var badges []*datastructure.Badge
-		if err := cache.Find(c.Context(), "badges", "", bson.M{}, &badges); err != nil {
-			return err
+	// Retrieve all users of badges
+	result := GetBadgesResult{
+		Badges: []*restutil.BadgeResponse{},
+	}
+	for _, baj := range badges {
+		var users []*datastructure.User
+		// Find directly assigned users
+		cur, err := mongo.Collection(mongo.CollectionNameUsers).Find(ctx, bson.M{
+			"_id": bson.M{"$in": baj.Users},
+		})
+		if err != nil {
+			log.WithError(err).WithField("badge", baj.Name).Error("mongo")
+			continue
+		}
+		if err = cur.All(ctx, &users); err != nil {
+			log.WithError(err).WithField("badge", baj.Name).Error("mongo")
+			continue
 		}
 
-		// Retrieve all users of badges
-		result := GetBadgesResult{
-			Badges: []*restutil.BadgeResponse{},
+		// Find entitled users
+		builders, err := actions.Entitlements.FetchEntitlements(ctx, struct {
+			Kind            *datastructure.EntitlementKind
+			ObjectReference primitive.ObjectID
+		}{
+			Kind:            &datastructure.EntitlementKindBadge,
+			ObjectReference: baj.ID,
+		})
+		if err != nil {
+			log.WithError(err).Error("GetBadges, FetchEntitlements")
 		}
-		for _, baj := range badges {
-			var users []*datastructure.User
-			// Find directly assigned users
-			cur, err := mongo.Collection(mongo.CollectionNameUsers).Find(ctx, bson.M{
-				"_id": bson.M{"$in": baj.Users},
-			})
-			if err != nil {
-				log.WithError(err).WithField("badge", baj.Name).Error("mongo")
-				continue
-			}
-			if err = cur.All(ctx, &users); err != nil {
-				log.WithError(err).WithField("badge", baj.Name).Error("mongo")
-				continue
-			}
+		for _, eb := range builders {
+			data := eb.ReadBadgeData()
+			ok := false
+			if data.RoleBinding != nil {
+				// Badge has role binding, we will now ensure user can actually use this badge
+				if eb.User.RoleID == data.RoleBinding {
+					ok = true
+				} else { // The user doesn't have the role bound directly, so we will check for an entitled role
+					ub, err := actions.Users.With(ctx, eb.User)
+					if err != nil {
+						log.WithError(err).WithField("badge", baj.Name).Error("actions")
+						continue
+					}
 
-			// Find entitled users
-			builders, err := actions.Entitlements.FetchEntitlements(ctx, struct {
-				Kind            *datastructure.EntitlementKind
-				ObjectReference primitive.ObjectID
-			}{
-				Kind:            &datastructure.EntitlementKindBadge,
-				ObjectReference: baj.ID,
-			})
-			if err != nil {
-				log.WithError(err).Error("GetBadges, FetchEntitlements")
-			}
-			for _, eb := range builders {
-				data := eb.ReadBadgeData()
-				ok := false
-				if data.RoleBinding != nil {
-					// Badge has role binding, we will now ensure user can actually use this badge
-					if eb.User.RoleID == data.RoleBinding {
-						ok = true
-					} else { // The user doesn't have the role bound directly, so we will check for an entitled role
-						ub, err := actions.Users.With(ctx, eb.User)
-						if err != nil {
-							log.WithError(err).WithField("badge", baj.Name).Error("actions")
+					uents, err := ub.FetchEntitlements(&datastructure.EntitlementKindRole)
+					if err != nil {
+						log.WithError(err).WithField("badge", baj.Name).Error("actions")
+					}
+					// Iterate role entitlements for the user
+					for _, uent := range uents {
+						role := uent.ReadRoleData()
+						if role.ObjectReference != *data.RoleBinding {
 							continue
 						}
-
-						uents, err := ub.FetchEntitlements(&datastructure.EntitlementKindRole)
-						if err != nil {
-							log.WithError(err).WithField("badge", baj.Name).Error("actions")
-						}
-						// Iterate role entitlements for the user
-						for _, uent := range uents {
-							role := uent.ReadRoleData()
-							if role.ObjectReference != *data.RoleBinding {
-								continue
-							}
-							ok = true
-						}
+						ok = true
 					}
-				} else {
-					ok = true
 				}
-				if !ok { // No permission to use this badge. Unlucky
-					continue
-				}
-
-				users = append(users, eb.User)
+			} else {
+				ok = true
+			}
+			if !ok { // No permission to use this badge. Unlucky
+				continue
 			}
-			b := restutil.CreateBadgeResponse(baj, users, idType)
 
-			result.Badges = append(result.Badges, b)
+			users = append(users, eb.User)
 		}
+		b := restutil.CreateBadgeResponse(baj, users, idType)
 
-		b, err := json.Marshal(&result)
-		if err != nil {
-			return restutil.ErrInternalServer().Send(c, err.Error())
-		}
-		return c.Status(200).Send(b)
-	})
+		result.Badges = append(result.Badges, b)
+	}
+
+	b, err := json.Marshal(&result)
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+	return restutil.SendCached(c, b, time.Time{})
 }
 
 type GetBadgesResult struct {
@@ -11,16 +11,15 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	"github.com/SevenTV/ServerGo/src/aws"
 	"github.com/SevenTV/ServerGo/src/configure"
-	"github.com/SevenTV/ServerGo/src/discord"
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/restutil"
 	"github.com/SevenTV/ServerGo/src/server/middleware"
+	"github.com/SevenTV/ServerGo/src/storage"
 	"github.com/SevenTV/ServerGo/src/utils"
 	"github.com/SevenTV/ServerGo/src/validation"
 	"github.com/gofiber/fiber/v2"
@@ -28,7 +27,6 @@ import (
 	log "github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"gopkg.in/gographics/imagick.v3/imagick"
 )
 
 const MAX_FRAME_COUNT = 4096
@@ -37,12 +35,11 @@ const MAX_PIXEL_HEIGHT = 3000
 const MAX_PIXEL_WIDTH = 3000
 
 func CreateEmoteRoute(router fiber.Router) {
-
-	rl := configure.Config.GetIntSlice("limits.route.emote-create")
 	router.Post(
 		"/",
 		middleware.UserAuthMiddleware(true),
-		middleware.RateLimitMiddleware("emote-create", int32(rl[0]), time.Millisecond*time.Duration(rl[1])),
+		middleware.RequireScope(datastructure.AccessTokenScopeEmotesWrite),
+		middleware.RouteRateLimitMiddleware("emote-create"),
 		func(c *fiber.Ctx) error {
 			c.Set("Content-Type", "application/json")
 			usr, ok := c.Locals("user").(*datastructure.User)
@@ -160,7 +157,7 @@ func CreateEmoteRoute(router fiber.Router) {
 					switch contentType {
 					case "image/jpeg":
 						ext = "jpg"
-					case "image/png":
+					case "image/png", "image/apng":
 						ext = "png"
 					case "image/gif":
 						ext = "gif"
@@ -223,14 +220,43 @@ func CreateEmoteRoute(router fiber.Router) {
 				}
 			}
 
+			// The emote counts against the upload quota of the channel which will own it
+			owner := usr
+			if *channelID != usr.ID {
+				ub, err := actions.Users.GetByID(c.Context(), *channelID)
+				if err != nil {
+					if err == mongo.ErrNoDocuments {
+						return restutil.ErrUnknownUser().Send(c)
+					}
+					log.WithError(err).Error("mongo")
+					return restutil.ErrInternalServer().Send(c)
+				}
+				owner = &ub.User
+			}
+			quota, err := actions.Emotes.GetUploadQuota(c.Context(), owner)
+			if err != nil {
+				log.WithError(err).Error("mongo")
+				return restutil.ErrInternalServer().Send(c)
+			}
+			if quota.DailyRemaining() == 0 {
+				c.Set("Retry-After", strconv.Itoa(int(time.Until(quota.DailyResetAt).Seconds())+1))
+				return restutil.ErrDailyUploadQuotaExceeded().Send(c, quota.DailyResetAt.UTC().Format(time.RFC3339))
+			}
+			if quota.OwnedRemaining() == 0 {
+				return restutil.ErrOwnedEmoteQuotaExceeded().Send(c, strconv.Itoa(int(quota.OwnedLimit)))
+			}
+
 			// Get uploaded image file into an image.Image
 			ogFile, err := os.Open(ogFilePath)
 			if err != nil {
 				log.WithError(err).Error("could not open original file")
 				return restutil.ErrInternalServer().Send(c)
 			}
+			defer ogFile.Close()
+
 			ogHeight := 0
 			ogWidth := 0
+			frameCount := 1
 			switch ext {
 			case "jpg":
 				img, err := jpeg.Decode(ogFile)
@@ -248,6 +274,15 @@ func CreateEmoteRoute(router fiber.Router) {
 				}
 				ogWidth = img.Bounds().Dx()
 				ogHeight = img.Bounds().Dy()
+
+				// Check for an animated PNG
+				if _, err = ogFile.Seek(0, io.SeekStart); err != nil {
+					log.WithError(err).Error("seek")
+					return restutil.ErrInternalServer().Send(c)
+				}
+				if frameCount, err = getPNGFrameCount(ogFile); err != nil {
+					return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Couldn't decode PNG: %v", err.Error()))
+				}
 			case "gif":
 				g, err := gif.DecodeAll(ogFile)
 				if err != nil {
@@ -255,118 +290,60 @@ func CreateEmoteRoute(router fiber.Router) {
 					return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Couldn't decode GIF: %v", err.Error()))
 				}
 
-				// Set a cap on how many frames are allowed
-				if len(g.Image) > MAX_FRAME_COUNT {
-					return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Maximum Frame Count Exceeded (%v)", MAX_FRAME_COUNT))
-				}
-
+				frameCount = len(g.Image)
 				ogWidth, ogHeight = getGifDimensions(g)
 			case "webp":
-				return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Sorry, direct support for WebP uploads is not available yet."))
+				ogWidth, ogHeight, frameCount, err = getWebPMeta(ogFile)
+				if err != nil {
+					return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Couldn't decode WebP: %v", err.Error()))
+				}
 			default:
 				return restutil.ErrBadRequest().Send(c, "Unsupported File Format")
 			}
+
+			// Set a cap on how many frames are allowed
+			if frameCount > MAX_FRAME_COUNT {
+				return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Maximum Frame Count Exceeded (%v)", MAX_FRAME_COUNT))
+			}
 			if ogWidth > MAX_PIXEL_WIDTH || ogHeight > MAX_PIXEL_HEIGHT {
 				return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Too Many Pixels (maximum %dx%d)", MAX_PIXEL_WIDTH, MAX_PIXEL_HEIGHT))
 			}
 
-			files := datastructure.EmoteUtil.GetFilesMeta(fileDir)
-			mime := "image/webp"
-
-			sizeX := [4]int16{0, 0, 0, 0}
-			sizeY := [4]int16{0, 0, 0, 0}
-			// Resize the frame(s)
-			for i, file := range files {
-				scope := file[1]
-				sizes := strings.Split(file[2], "x")
-				maxWidth, _ := strconv.ParseFloat(sizes[0], 4)
-				maxHeight, _ := strconv.ParseFloat(sizes[1], 4)
-				quality := file[3]
-				outFile := fmt.Sprintf("%v/%v.webp", fileDir, scope)
-
-				// Get calculed ratio for the size
-				width, height := utils.GetSizeRatio(
-					[]float64{float64(ogWidth), float64(ogHeight)},
-					[]float64{maxWidth, maxHeight},
-				)
-				sizeX[i] = int16(width)
-				sizeY[i] = int16(height)
-
-				// Create new boundaries for frames
-				mw := imagick.NewMagickWand() // Get magick wand & read the original image
-				if err = mw.SetResourceLimit(imagick.RESOURCE_MEMORY, 500); err != nil {
-					log.WithError(err).Error("SetResourceLimit")
-				}
-				if err := mw.ReadImage(ogFilePath); err != nil {
-					return restutil.ErrBadRequest().Send(c, fmt.Sprintf("Input File Not Readable: %s", err))
-				}
-
-				// Merge all frames with coalesce
-				aw := mw.CoalesceImages()
-				if err = aw.SetResourceLimit(imagick.RESOURCE_MEMORY, 500); err != nil {
-					log.WithError(err).Error("SetResourceLimit")
-				}
-				mw.Destroy()
-				defer aw.Destroy()
-
-				// Set delays
-				mw = imagick.NewMagickWand()
-				if err = mw.SetResourceLimit(imagick.RESOURCE_MEMORY, 500); err != nil {
-					log.WithError(err).Error("SetResourceLimit")
-				}
-				defer mw.Destroy()
-
-				// Add each frame to our animated image
-				mw.ResetIterator()
-				for ind := 0; ind < int(aw.GetNumberImages()); ind++ {
-					aw.SetIteratorIndex(ind)
-					img := aw.GetImage()
-
-					if err = img.ResizeImage(uint(width), uint(height), imagick.FILTER_LANCZOS); err != nil {
-						log.WithError(err).Errorf("ResizeImage i=%v", ind)
-						continue
-					}
-					if err = mw.AddImage(img); err != nil {
-						log.WithError(err).Errorf("AddImage i=%v", ind)
-					}
-					img.Destroy()
-				}
-
-				// Done - convert to WEBP
-				q, _ := strconv.Atoi(quality)
-				if err = mw.SetImageCompressionQuality(uint(q)); err != nil {
-					log.WithError(err).Error("SetImageCompressionQuality")
-				}
-				if err = mw.SetImageFormat("webp"); err != nil {
-					log.WithError(err).Error("SetImageFormat")
-				}
-
-				// Write to file
-				err = mw.WriteImages(outFile, true)
-				if err != nil {
-					log.WithError(err).Error("cmd")
-					return restutil.ErrInternalServer().Send(c)
-				}
+			// Store the original file where the processing workers of every pod can read it
+			ogData, err := os.ReadFile(ogFilePath)
+			if err != nil {
+				log.WithError(err).Error("could not read original file")
+				return restutil.ErrInternalServer().Send(c)
+			}
+			uploadKey := storage.UploadsPrefix + id.String()
+			if err := storage.UploadPrivateFile(configure.Config.GetString("aws_cdn_bucket"), uploadKey, ogData); err != nil {
+				log.WithError(err).Error("storage")
+				return restutil.ErrInternalServer().Send(c)
+			}
+			job := actions.EmoteProcessingJob{
+				ActorID:   usr.ID,
+				UploadKey: uploadKey,
+				APNG:      ext == "png" && frameCount > 1,
+				Width:     ogWidth,
+				Height:    ogHeight,
 			}
 
-			wg := &sync.WaitGroup{}
-			wg.Add(len(files))
-
+			mime := "image/webp"
 			emote = &datastructure.Emote{
 				Name:             emoteName,
 				Mime:             mime,
+				Animated:         frameCount > 1,
 				Status:           datastructure.EmoteStatusProcessing,
 				Tags:             utils.Ternary(emoteTags != nil, emoteTags, []string{}).([]string),
 				Visibility:       emoteVisibility | datastructure.EmoteVisibilityUnlisted,
 				OwnerID:          *channelID,
 				LastModifiedDate: time.Now(),
-				Width:            sizeX,
-				Height:           sizeY,
 			}
 			res, err := mongo.Collection(mongo.CollectionNameEmotes).InsertOne(c.Context(), emote)
 
 			if err != nil {
 				log.WithError(err).Error("mongo")
+				actions.Emotes.DeleteUpload(job)
 				return restutil.ErrInternalServer().Send(c)
 			}
 
@@ -379,52 +356,26 @@ func CreateEmoteRoute(router fiber.Router) {
 				if err != nil {
 					log.WithError(err).Error("mongo")
 				}
+				actions.Emotes.DeleteUpload(job)
 				return restutil.ErrInternalServer().Send(c)
 			}
 
 			emote.ID = _id
-			errored := false
-
-			for _, path := range files {
-				go func(path []string) {
-					defer wg.Done()
-					data, err := os.ReadFile(path[0] + ".webp")
-					if err != nil {
-						log.WithError(err).Error("read")
-						errored = true
-						return
-					}
-
-					if err := aws.UploadFile(configure.Config.GetString("aws_cdn_bucket"), fmt.Sprintf("emote/%s/%s", _id.Hex(), path[1]), data, &mime); err != nil {
-						log.WithError(err).Error("aws")
-						errored = true
-					}
-				}(path)
-			}
-
-			wg.Wait()
+			job.EmoteID = _id
 
-			if errored {
+			// Hand the upload over to the processing workers
+			if err := actions.Emotes.EnqueueProcessing(c.Context(), job); err != nil {
+				log.WithError(err).Error("redis")
 				_, err := mongo.Collection(mongo.CollectionNameEmotes).DeleteOne(c.Context(), bson.M{
 					"_id": _id,
 				})
 				if err != nil {
 					log.WithError(err).WithField("id", id).Error("mongo")
 				}
+				actions.Emotes.DeleteUpload(job)
 				return restutil.ErrInternalServer().Send(c)
 			}
 
-			_, err = mongo.Collection(mongo.CollectionNameEmotes).UpdateOne(c.Context(), bson.M{
-				"_id": _id,
-			}, bson.M{
-				"$set": bson.M{
-					"status": datastructure.EmoteStatusLive,
-				},
-			})
-			if err != nil {
-				log.WithError(err).WithField("id", id).Error("mongo")
-			}
-
 			_, err = mongo.Collection(mongo.CollectionNameAudit).InsertOne(c.Context(), &datastructure.AuditLog{
 				Type: datastructure.AuditLogTypeEmoteCreate,
 				Changes: []*datastructure.AuditLogChange{
@@ -442,11 +393,19 @@ func CreateEmoteRoute(router fiber.Router) {
 				log.WithError(err).Error("mongo")
 			}
 
-			go discord.SendEmoteCreate(*emote, *usr)
-			return c.SendString(fmt.Sprintf(`{"id":"%v"}`, emote.ID.Hex()))
+			// The emote goes live once processed, poll /emotes/:emote/status or subscribe to /events/emote-status
+			return c.Status(fiber.StatusAccepted).JSON(&createEmoteResponse{
+				ID:     emote.ID.Hex(),
+				Status: emote.Status,
+			})
 		})
 }
 
+type createEmoteResponse struct {
+	ID     string `json:"id"`
+	Status int32  `json:"status"`
+}
+
 func getGifDimensions(gif *gif.GIF) (x, y int) {
 	var leastX int
 	var leastY int
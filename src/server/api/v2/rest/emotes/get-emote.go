@@ -27,44 +27,11 @@ var (
 
 func GetEmoteRoute(router fiber.Router) {
 	// Get Emote
-	router.Get("/:emote", middleware.RateLimitMiddleware("get-emote", 30, 6*time.Second),
-		func(c *fiber.Ctx) error {
-			// Parse Emote ID
-			id, err := primitive.ObjectIDFromHex(c.Params("emote"))
-			if err != nil {
-				return restutil.MalformedObjectId().Send(c)
-			}
-
-			// Fetch emote data
-			var emote datastructure.Emote
-			if err := cache.FindOne(c.Context(), "emotes", "", bson.M{
-				"_id": id,
-			}, &emote); err != nil {
-				if err == mongo.ErrNoDocuments {
-					return restutil.ErrUnknownEmote().Send(c)
-				}
-				return restutil.ErrInternalServer().Send(c, err.Error())
-			}
-
-			// Fetch emote owner
-			var owner *datastructure.User
-			if err := cache.FindOne(c.Context(), "users", "", bson.M{
-				"_id": emote.OwnerID,
-			}, &owner); err != nil {
-				if err != mongo.ErrNoDocuments {
-					return restutil.ErrInternalServer().Send(c, err.Error())
-				}
-			}
-
-			response := restutil.CreateEmoteResponse(&emote, owner)
-
-			b, err := json.Marshal(&response)
-			if err != nil {
-				return restutil.ErrInternalServer().Send(c, err.Error())
-			}
+	router.Get("/:emote", middleware.RateLimitMiddleware("get-emote", 30, 6*time.Second), GetEmoteHandler)
 
-			return c.Send(b)
-		})
+	// Get Emote Status
+	// Not cached, used by clients waiting for an upload to be processed
+	router.Get("/:emote/status", middleware.RateLimitMiddleware("get-emote-status", 60, 6*time.Second), GetEmoteStatusHandler)
 
 	// OEmbed
 	router.Get("/oembed/:emote.json", func(c *fiber.Ctx) error {
@@ -106,6 +73,86 @@ func GetEmoteRoute(router fiber.Router) {
 	})
 }
 
+// Get an emote
+func GetEmoteHandler(c *fiber.Ctx) error {
+	// Parse Emote ID
+	id, err := primitive.ObjectIDFromHex(c.Params("emote"))
+	if err != nil {
+		return restutil.MalformedObjectId().Send(c)
+	}
+
+	// Fetch emote data
+	var emote datastructure.Emote
+	if err := cache.FindOne(c.Context(), "emotes", "", bson.M{
+		"_id": id,
+	}, &emote); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return restutil.ErrUnknownEmote().Send(c)
+		}
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	// Fetch emote owner
+	var owner *datastructure.User
+	if err := cache.FindOne(c.Context(), "users", "", bson.M{
+		"_id": emote.OwnerID,
+	}, &owner); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return restutil.ErrInternalServer().Send(c, err.Error())
+		}
+	}
+
+	response := restutil.CreateEmoteResponse(&emote, owner)
+
+	b, err := json.Marshal(&response)
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	return restutil.SendCached(c, b, emote.LastModifiedDate)
+}
+
+// Get the processing status of an emote, not cached
+func GetEmoteStatusHandler(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("emote"))
+	if err != nil {
+		return restutil.MalformedObjectId().Send(c)
+	}
+
+	var emote datastructure.Emote
+	if err := mongo.Collection(mongo.CollectionNameEmotes).FindOne(c.Context(), bson.M{
+		"_id": id,
+	}).Decode(&emote); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return restutil.ErrUnknownEmote().Send(c)
+		}
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	similar := make([]string, len(emote.Similar))
+	for i, id := range emote.Similar {
+		similar[i] = id.Hex()
+	}
+	b, err := json.Marshal(&EmoteStatusResponse{
+		ID:            emote.ID.Hex(),
+		Status:        emote.Status,
+		StatusMessage: emote.StatusMessage,
+		Similar:       similar,
+	})
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	return c.Send(b)
+}
+
+type EmoteStatusResponse struct {
+	ID            string   `json:"id"`
+	Status        int32    `json:"status"`
+	StatusMessage string   `json:"status_message,omitempty"`
+	Similar       []string `json:"similar,omitempty"` // Existing emotes which looked like the upload when it was processed
+}
+
 type OEmbedData struct {
 	Title        string `json:"title"`
 	AuthorName   string `json:"author_name"`
@@ -0,0 +1,153 @@
+package emotes
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+var (
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	riffSignature = []byte("RIFF")
+	webpSignature = []byte("WEBP")
+)
+
+// Read the dimensions and frame count of a WebP file, static or animated.
+// Only the RIFF container and the image headers are read, frames are not decoded
+func getWebPMeta(r io.Reader) (width, height, frames int, err error) {
+	header := make([]byte, 12)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return 0, 0, 0, fmt.Errorf("missing RIFF header")
+	}
+	if !bytes.Equal(header[0:4], riffSignature) || !bytes.Equal(header[8:12], webpSignature) {
+		return 0, 0, 0, fmt.Errorf("not a WebP file")
+	}
+
+	animated := false
+	chunkHeader := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunkHeader); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, 0, 0, fmt.Errorf("truncated chunk header")
+		}
+
+		fourCC := string(chunkHeader[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+		padded := size + size&1 // Chunks are padded to an even size
+
+		switch fourCC {
+		case "VP8X": // Extended format: canvas size and feature flags
+			data, err := readChunk(r, size, padded, 10)
+			if err != nil {
+				return 0, 0, 0, err
+			}
+
+			animated = data[0]&0x02 != 0
+			width = int(uint32(data[4])|uint32(data[5])<<8|uint32(data[6])<<16) + 1
+			height = int(uint32(data[7])|uint32(data[8])<<8|uint32(data[9])<<16) + 1
+		case "VP8 ": // Lossy bitstream
+			data, err := readChunk(r, size, padded, 10)
+			if err != nil {
+				return 0, 0, 0, err
+			}
+			if data[3] != 0x9d || data[4] != 0x01 || data[5] != 0x2a {
+				return 0, 0, 0, fmt.Errorf("invalid VP8 start code")
+			}
+
+			if width == 0 {
+				width = int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+				height = int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+			}
+			if !animated {
+				frames = 1
+			}
+		case "VP8L": // Lossless bitstream
+			data, err := readChunk(r, size, padded, 5)
+			if err != nil {
+				return 0, 0, 0, err
+			}
+			if data[0] != 0x2f {
+				return 0, 0, 0, fmt.Errorf("invalid VP8L signature")
+			}
+
+			if width == 0 {
+				bits := binary.LittleEndian.Uint32(data[1:5])
+				width = int(bits&0x3fff) + 1
+				height = int((bits>>14)&0x3fff) + 1
+			}
+			if !animated {
+				frames = 1
+			}
+		case "ANMF": // Animation frame
+			frames++
+			if _, err := io.CopyN(io.Discard, r, padded); err != nil {
+				return 0, 0, 0, fmt.Errorf("truncated ANMF chunk")
+			}
+		default:
+			if _, err := io.CopyN(io.Discard, r, padded); err != nil {
+				return 0, 0, 0, fmt.Errorf("truncated %s chunk", fourCC)
+			}
+		}
+	}
+
+	if width == 0 || height == 0 || frames == 0 {
+		return 0, 0, 0, fmt.Errorf("no image data")
+	}
+	return width, height, frames, nil
+}
+
+// Read the frame count of a PNG file.
+// Static PNGs have a single frame, APNGs declare their frame count in the acTL chunk preceding the image data
+func getPNGFrameCount(r io.Reader) (frames int, err error) {
+	signature := make([]byte, len(pngSignature))
+	if _, err = io.ReadFull(r, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return 0, fmt.Errorf("not a PNG file")
+	}
+
+	chunkHeader := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunkHeader); err != nil {
+			return 0, fmt.Errorf("truncated chunk header")
+		}
+
+		size := int64(binary.BigEndian.Uint32(chunkHeader[0:4]))
+		switch string(chunkHeader[4:8]) {
+		case "acTL": // Animation control: number of frames and plays
+			data, err := readChunk(r, size, size+4, 8)
+			if err != nil {
+				return 0, err
+			}
+
+			frames = int(binary.BigEndian.Uint32(data[0:4]))
+			if frames == 0 {
+				return 0, fmt.Errorf("invalid APNG frame count")
+			}
+			return frames, nil
+		case "IDAT", "IEND": // The animation control must come before the image data
+			return 1, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+4); err != nil { // Chunk data and CRC
+				return 0, fmt.Errorf("truncated chunk")
+			}
+		}
+	}
+}
+
+// Read a chunk's data, requiring at least min bytes, and skip over the remainder
+func readChunk(r io.Reader, size, padded int64, min int) ([]byte, error) {
+	if size < int64(min) {
+		return nil, fmt.Errorf("chunk too small")
+	}
+
+	data := make([]byte, min)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("truncated chunk")
+	}
+	if _, err := io.CopyN(io.Discard, r, padded-int64(min)); err != nil {
+		return nil, fmt.Errorf("truncated chunk")
+	}
+
+	return data, nil
+}
@@ -0,0 +1,161 @@
+package emotes
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/restutil"
+	"github.com/SevenTV/ServerGo/src/server/middleware"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The most items returned in a page
+const maxPageSize = 150
+
+// Parse the "limit" and "after" query parameters of a paginated route
+func getPageParams(c *fiber.Ctx) (int64, *actions.Cursor, *restutil.ErrorResponse) {
+	limit := int64(20)
+	if s := c.Query("limit"); s != "" {
+		l, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || l < 1 || l > maxPageSize {
+			return 0, nil, restutil.ErrBadRequest()
+		}
+		limit = l
+	}
+
+	var after *actions.Cursor
+	if s := c.Query("after"); s != "" {
+		cursor, ok := actions.DecodeCursor(s)
+		if !ok {
+			return 0, nil, restutil.ErrBadRequest()
+		}
+		after = &cursor
+	}
+
+	return limit, after, nil
+}
+
+func SearchEmotesRoute(router fiber.Router) {
+	// Search Emotes
+	// Paginated by cursor, the next page is linked in the Link header
+	router.Get("/", middleware.RateLimitMiddleware("search-emotes", 30, 6*time.Second), SearchEmotesHandler)
+
+	// Get Emote Channels
+	// The channels which added an emote, highest role first. Paginated by cursor
+	router.Get("/:emote/channels", middleware.RateLimitMiddleware("get-emote-channels", 30, 6*time.Second), GetEmoteChannelsHandler)
+}
+
+// Search live emotes, a page at a time. The next page is linked in the Link header
+func SearchEmotesHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	limit, after, errResp := getPageParams(c)
+	if errResp != nil {
+		return errResp.Send(c, "invalid limit or cursor")
+	}
+
+	usr, _ := c.Locals("user").(*datastructure.User)
+	opts := actions.EmoteSearchOptions{
+		Query:         c.Query("query"),
+		GlobalState:   c.Query("global_state"),
+		Actor:         usr,
+		SortDirection: -1,
+	}
+	if channel := c.Query("channel"); channel != "" {
+		opts.Channel = &channel
+	}
+	switch c.Query("sort_by") {
+	case "", "age":
+	case "popularity":
+		opts.SortBy = "popularity"
+	default:
+		return restutil.ErrBadRequest().Send(c, "sort_by must be popularity or age")
+	}
+	switch c.Query("sort_order") {
+	case "", "desc":
+	case "asc":
+		opts.SortDirection = 1
+	default:
+		return restutil.ErrBadRequest().Send(c, "sort_order must be asc or desc")
+	}
+
+	emotes, page, err := actions.Emotes.Search(ctx, opts, limit, after)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	// Find the owners of the emotes
+	ownerIDs := []primitive.ObjectID{}
+	for _, emote := range emotes {
+		ownerIDs = append(ownerIDs, emote.OwnerID)
+	}
+	owners := []*datastructure.User{}
+	cur, err := mongo.Collection(mongo.CollectionNameUsers).Find(ctx, bson.M{
+		"_id": bson.M{
+			"$in": ownerIDs,
+		},
+	})
+	if err == nil {
+		err = cur.All(ctx, &owners)
+	}
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+	ownerMap := make(map[primitive.ObjectID]*datastructure.User, len(owners))
+	for _, o := range owners {
+		ownerMap[o.ID] = o
+	}
+
+	response := make([]restutil.EmoteResponse, len(emotes))
+	for i, emote := range emotes {
+		response[i] = restutil.CreateEmoteResponse(emote, ownerMap[emote.OwnerID])
+	}
+
+	j, err := json.Marshal(response)
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	restutil.SetPageHeaders(c, page)
+	return c.Send(j)
+}
+
+// Get the channels which added an emote, highest role first, a page at a time
+func GetEmoteChannelsHandler(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("emote"))
+	if err != nil {
+		return restutil.MalformedObjectId().Send(c)
+	}
+
+	limit, after, errResp := getPageParams(c)
+	if errResp != nil {
+		return errResp.Send(c, "invalid limit or cursor")
+	}
+
+	users, page, err := actions.Emotes.GetChannels(c.Context(), id, limit, after)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	response := []*restutil.UserResponse{}
+	for _, u := range users {
+		response = append(response, restutil.CreateUserResponse(u))
+	}
+
+	j, err := json.Marshal(response)
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	restutil.SetPageHeaders(c, page)
+	return c.Send(j)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/cosmetics"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/emotes"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/users"
+	"github.com/SevenTV/ServerGo/src/server/middleware"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/rewrite/v2"
 )
@@ -20,6 +21,8 @@ func RestV2(app fiber.Router) fiber.Router {
 
 		return c.Next()
 	})
+	// Callers are optionally authenticated, so that rate limits apply the tier of their role or access token
+	restGroup.Use(middleware.UserAuthMiddleware(false))
 	restGroup.Use(rewrite.New(rewrite.Config{
 		Rules: map[string]string{
 			"/v2/badges": "/v2/cosmetics",
@@ -29,13 +32,18 @@ func RestV2(app fiber.Router) fiber.Router {
 	emoteGroup := restGroup.Group("/emotes")
 	emotes.CreateEmoteRoute(emoteGroup)
 	emotes.GetGlobalEmotes(emoteGroup)
+	emotes.SearchEmotesRoute(emoteGroup)
 	emotes.GetEmoteRoute(emoteGroup)
 
 	userGroup := restGroup.Group("/users")
 	users.GetUser(userGroup)
 	users.GetChannelEmotesRoute(userGroup)
+	users.GetUserEditors(userGroup)
 	users.EditProfilePicture(userGroup)
 
+	channelGroup := restGroup.Group("/channels")
+	users.GetBulkChannelEmotesRoute(channelGroup)
+
 	cosmeticsGroup := restGroup.Group("/cosmetics")
 	cosmetics.GetBadges(cosmeticsGroup)
 
@@ -114,19 +122,6 @@ type PlatformsResult struct {
 	Platforms []*Platform `json:"platforms"`
 }
 
-type Platform struct {
-	ID         string             `mapstructure:"id" json:"id"`
-	VersionTag string             `mapstructure:"version_tag" json:"version_tag"`
-	New        bool               `mapstructure:"new" json:"new"`
-	URL        string             `mapstructure:"url" json:"url"`
-	Variants   *[]PlatformVariant `mapstructure:"variants" json:"variants"`
-}
+type Platform = configure.Platform
 
-type PlatformVariant struct {
-	Name        string `json:"name" mapstructure:"name"`
-	ID          string `json:"id" mapstructure:"id"`
-	Author      string `json:"author" mapstructure:"author"`
-	Version     string `json:"version" mapstructure:"version"`
-	Description string `json:"description" mapstructure:"description"`
-	URL         string `json:"url" mapstructure:"url"`
-}
+type PlatformVariant = configure.PlatformVariant
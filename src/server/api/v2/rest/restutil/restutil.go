@@ -1,13 +1,19 @@
 package restutil
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
 	"github.com/SevenTV/ServerGo/src/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
 )
 
 type ErrorResponse struct {
@@ -41,6 +47,10 @@ var (
 	ErrLoginRequired      = func() *ErrorResponse { return createErrorResponse(403, "Authentication Required") }
 	ErrAccessDenied       = func() *ErrorResponse { return createErrorResponse(403, "Insufficient Privilege") }
 	ErrMissingQueryParams = func() *ErrorResponse { return createErrorResponse(400, "Missing Query Params (%s)") }
+	ErrUnknownRoute       = func() *ErrorResponse { return createErrorResponse(404, "Unknown Route") }
+
+	ErrDailyUploadQuotaExceeded = func() *ErrorResponse { return createErrorResponse(429, "Daily Upload Quota Exceeded (resets at %s)") }
+	ErrOwnedEmoteQuotaExceeded  = func() *ErrorResponse { return createErrorResponse(403, "Owned Emote Quota Exceeded (at most %s)") }
 )
 
 func CreateEmoteResponse(emote *datastructure.Emote, owner *datastructure.User) EmoteResponse {
@@ -66,6 +76,7 @@ func CreateEmoteResponse(emote *datastructure.Emote, owner *datastructure.User)
 		VisibilitySimple: &simpleVis,
 		Mime:             emote.Mime,
 		Status:           emote.Status,
+		StatusMessage:    emote.StatusMessage,
 		Tags:             utils.Ternary(emote.Tags != nil, emote.Tags, []string{}).([]string),
 		Width:            emote.Width,
 		Height:           emote.Height,
@@ -86,6 +97,7 @@ type EmoteResponse struct {
 	VisibilitySimple *[]string     `json:"visibility_simple"`
 	Mime             string        `json:"mime"`
 	Status           int32         `json:"status"`
+	StatusMessage    string        `json:"status_message,omitempty"`
 	Tags             []string      `json:"tags"`
 	Width            [4]int16      `json:"width"`
 	Height           [4]int16      `json:"height"`
@@ -168,3 +180,53 @@ type BadgeResponse struct {
 	Users   []string   `json:"users"`
 	Misc    bool       `json:"misc,omitempty"`
 }
+
+// SetPageHeaders: Set the size of a paginated collection, and link to its next page by its cursor
+func SetPageHeaders(c *fiber.Ctx, page actions.Page) {
+	c.Set("X-Collection-Size", fmt.Sprint(page.TotalCount))
+	if !page.HasNextPage {
+		return
+	}
+
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+	c.Context().QueryArgs().CopyTo(args)
+	args.Set("after", page.EndCursor)
+
+	c.Set("Link", fmt.Sprintf("<%s%s?%s>; rel=\"next\"", c.BaseURL(), c.Path(), args.String()))
+}
+
+// GetLastModified: Get the most recent time any of the emotes was modified
+func GetLastModified(emotes []*datastructure.Emote) time.Time {
+	var t time.Time
+	for _, e := range emotes {
+		if e.LastModifiedDate.After(t) {
+			t = e.LastModifiedDate
+		}
+	}
+
+	return t
+}
+
+// SendCached: Send a body with a strong ETag of its content, and when it was last modified if known.
+// Responds 304 Not Modified instead if the client's copy is still current, as told by If-None-Match
+func SendCached(c *fiber.Ctx, body []byte, lastModified time.Time) error {
+	sum := sha256.Sum256(body)
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:16]))
+
+	c.Set(fiber.HeaderETag, etag)
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// Only the ETag is trusted to tell if a copy is current, as a list can change without any of its items being modified
+	for _, tag := range strings.Split(c.Get(fiber.HeaderIfNoneMatch), ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == etag || tag == "W/"+etag || tag == "*" {
+			c.Status(fiber.StatusNotModified)
+			return nil
+		}
+	}
+
+	return c.Send(body)
+}
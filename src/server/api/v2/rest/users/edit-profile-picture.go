@@ -16,12 +16,12 @@ import (
 	"github.com/google/uuid"
 	"github.com/sizeofint/webpanimation"
 
-	"github.com/SevenTV/ServerGo/src/aws"
 	"github.com/SevenTV/ServerGo/src/configure"
 	"github.com/SevenTV/ServerGo/src/mongo"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/restutil"
 	"github.com/SevenTV/ServerGo/src/server/middleware"
+	"github.com/SevenTV/ServerGo/src/storage"
 	"github.com/SevenTV/ServerGo/src/utils"
 	"github.com/gofiber/fiber/v2"
 	log "github.com/sirupsen/logrus"
@@ -31,7 +31,7 @@ const MAX_PIXEL_HEIGHT = 1000
 const MAX_PIXEL_WIDTH = 1000
 
 func EditProfilePicture(router fiber.Router) {
-	router.Post("/profile-picture", middleware.UserAuthMiddleware(true), func(c *fiber.Ctx) error {
+	router.Post("/profile-picture", middleware.UserAuthMiddleware(true), middleware.RequireScope(datastructure.AccessTokenScopeUsersWrite), func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "application/json")
 		req := c.Request()
 		ctx := c.Context()
@@ -124,7 +124,7 @@ func EditProfilePicture(router fiber.Router) {
 		id := uuid.New()
 		idb, _ := id.MarshalBinary()
 		strId := hex.EncodeToString(idb)
-		if err = aws.UploadFile(
+		if err = storage.UploadFile(
 			configure.Config.GetString("aws_cdn_bucket"),
 			fmt.Sprintf("pp/%s/%s", user.ID.Hex(), strId),
 			b.Bytes(),
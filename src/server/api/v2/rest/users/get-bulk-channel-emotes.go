@@ -0,0 +1,99 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/restutil"
+	"github.com/SevenTV/ServerGo/src/server/middleware"
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetBulkChannelEmotesRoute(router fiber.Router) {
+	router.Get("/emotes", middleware.RateLimitMiddleware("get-bulk-channel-emotes", 20, 9*time.Second), GetBulkChannelEmotesHandler)
+}
+
+// Get the emotes of several channels at once, mapped by the identifiers in the "channels" query parameter
+func GetBulkChannelEmotesHandler(c *fiber.Ctx) error {
+	ctx := c.Context()
+	c.Set("Cache-Control", "max-age=30")
+
+	identifiers := []string{}
+	for _, s := range strings.Split(c.Query("channels"), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			identifiers = append(identifiers, s)
+		}
+	}
+	if len(identifiers) == 0 {
+		return restutil.ErrMissingQueryParams().Send(c, "channels")
+	}
+	if limit := actions.Emotes.GetChannelEmotesLimit(); len(identifiers) > limit {
+		return restutil.ErrBadRequest().Send(c, fmt.Sprintf("at most %d channels can be requested", limit))
+	}
+
+	channels, err := actions.Emotes.GetChannelEmotes(ctx, identifiers)
+	if err != nil {
+		log.WithError(err).Error("mongo")
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	// Find the owners of the emotes of all channels, with only what the response shows of them
+	ownerIDs := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	emotes := []*datastructure.Emote{}
+	for _, ce := range channels {
+		for _, emote := range ce.Emotes {
+			if !seen[emote.OwnerID] {
+				seen[emote.OwnerID] = true
+				ownerIDs = append(ownerIDs, emote.OwnerID)
+			}
+			emotes = append(emotes, emote)
+		}
+	}
+	owners := []*datastructure.User{}
+	if len(ownerIDs) > 0 {
+		cur, err := mongo.Collection(mongo.CollectionNameUsers).Find(ctx, bson.M{
+			"_id": bson.M{"$in": ownerIDs},
+		}, options.Find().SetProjection(bson.M{
+			"login":              1,
+			"display_name":       1,
+			"role":               1,
+			"profile_picture_id": 1,
+		}))
+		if err == nil {
+			err = cur.All(ctx, &owners)
+		}
+		if err != nil {
+			return restutil.ErrInternalServer().Send(c, err.Error())
+		}
+	}
+	ownerMap := make(map[primitive.ObjectID]*datastructure.User, len(owners))
+	for _, o := range owners {
+		ownerMap[o.ID] = o
+	}
+
+	response := make(map[string][]restutil.EmoteResponse, len(channels))
+	for identifier, ce := range channels {
+		list := make([]restutil.EmoteResponse, len(ce.Emotes))
+		for i, emote := range ce.Emotes {
+			list[i] = restutil.CreateEmoteResponse(emote, ownerMap[emote.OwnerID])
+		}
+		response[identifier] = list
+	}
+
+	j, err := json.Marshal(response)
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	return restutil.SendCached(c, j, restutil.GetLastModified(emotes))
+}
@@ -0,0 +1,54 @@
+package users
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/cache"
+	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
+	"github.com/SevenTV/ServerGo/src/server/api/actions"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/restutil"
+	"github.com/SevenTV/ServerGo/src/server/middleware"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func GetUserEditors(router fiber.Router) {
+	router.Get("/:user/editors", middleware.RateLimitMiddleware("get-user-editors", 30, 6*time.Second), GetUserEditorsHandler)
+}
+
+// Get the editors of a channel, who can manage its emotes
+func GetUserEditorsHandler(c *fiber.Ctx) error {
+	user, err := FindUser(c.Context(), c.Params("user"))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return restutil.ErrUnknownUser().Send(c)
+		}
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	editors := []*datastructure.User{}
+	if len(user.EditorIDs) > 0 {
+		if err := cache.Find(c.Context(), "users", "", bson.M{
+			"_id": bson.M{"$in": user.EditorIDs},
+		}, &editors); err != nil {
+			return restutil.ErrInternalServer().Send(c, err.Error())
+		}
+	}
+
+	response := []*restutil.UserResponse{}
+	for _, e := range editors {
+		if banned, _ := actions.Bans.IsUserBanned(e.ID); banned {
+			continue
+		}
+		response = append(response, restutil.CreateUserResponse(e))
+	}
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	return c.Send(b)
+}
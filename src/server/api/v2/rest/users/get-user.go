@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"encoding/json"
 	"strings"
 
@@ -14,31 +15,45 @@ import (
 )
 
 func GetUser(router fiber.Router) {
-	router.Get("/:user", func(c *fiber.Ctx) error {
-		id, err := primitive.ObjectIDFromHex(c.Params("user"))
-		if err != nil {
-			id = primitive.NilObjectID
-		}
+	router.Get("/:user", GetUserHandler)
+}
 
-		var user datastructure.User
-		if err := cache.FindOne(c.Context(), "users", "", bson.M{
-			"$or": bson.A{
-				bson.M{"_id": id},
-				bson.M{"login": strings.ToLower(c.Params("user"))},
-				bson.M{"id": strings.ToLower(c.Params("user"))},
-			},
-		}, &user); err != nil {
-			if err == mongo.ErrNoDocuments {
-				return restutil.ErrUnknownUser().Send(c)
-			}
+// Get a user by ID, login or Twitch ID
+func GetUserHandler(c *fiber.Ctx) error {
+	user, err := FindUser(c.Context(), c.Params("user"))
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return restutil.ErrUnknownUser().Send(c)
 		}
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
 
-		response := restutil.CreateUserResponse(&user)
-		b, err := json.Marshal(&response)
-		if err != nil {
-			return restutil.ErrInternalServer().Send(c, err.Error())
-		}
+	response := restutil.CreateUserResponse(user)
+	b, err := json.Marshal(&response)
+	if err != nil {
+		return restutil.ErrInternalServer().Send(c, err.Error())
+	}
+
+	return c.Send(b)
+}
+
+// FindUser: Find a user by ID, login or Twitch ID
+func FindUser(ctx context.Context, identifier string) (*datastructure.User, error) {
+	id, err := primitive.ObjectIDFromHex(identifier)
+	if err != nil {
+		id = primitive.NilObjectID
+	}
+
+	var user datastructure.User
+	if err := cache.FindOne(ctx, "users", "", bson.M{
+		"$or": bson.A{
+			bson.M{"_id": id},
+			bson.M{"login": strings.ToLower(identifier)},
+			bson.M{"id": strings.ToLower(identifier)},
+		},
+	}, &user); err != nil {
+		return nil, err
+	}
 
-		return c.Send(b)
-	})
+	return &user, nil
 }
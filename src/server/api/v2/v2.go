@@ -2,6 +2,7 @@ package v2
 
 import (
 	"github.com/SevenTV/ServerGo/src/server/api/v2/chatterino"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/events"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/gql"
 	"github.com/SevenTV/ServerGo/src/server/api/v2/rest"
 	"github.com/gofiber/fiber/v2"
@@ -12,7 +13,7 @@ func API(app fiber.Router) fiber.Router {
 	api := app.Group("/v2")
 	api.Use(cors.New(cors.Config{
 		AllowOrigins:  "*",
-		ExposeHeaders: "X-Collection-Size,X-Created-ID",
+		ExposeHeaders: "X-Collection-Size,X-Created-ID,ETag,Last-Modified",
 		AllowMethods:  "GET,POST,PUT,PATCH,DELETE",
 	}))
 
@@ -21,6 +22,7 @@ func API(app fiber.Router) fiber.Router {
 	rest.RestV2(api)
 	gql.GQL(api)
 	chatterino.Chatterino(api)
+	events.Events(api)
 
 	return api
 }
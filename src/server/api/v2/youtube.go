@@ -34,7 +34,7 @@ func YouTube(app fiber.Router) fiber.Router {
 		return route
 	}
 
-	route.Get("/request-verification", middleware.UserAuthMiddleware(true), func(c *fiber.Ctx) error {
+	route.Get("/request-verification", middleware.UserAuthMiddleware(true), middleware.RequireSession(), func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "application/json")
 		channelID := c.Query("channel_id")
 		if channelID == "" {
@@ -84,7 +84,7 @@ func YouTube(app fiber.Router) fiber.Router {
 		return c.Send(j)
 	})
 
-	route.Get("/verify", middleware.UserAuthMiddleware(true), func(c *fiber.Ctx) error {
+	route.Get("/verify", middleware.UserAuthMiddleware(true), middleware.RequireSession(), func(c *fiber.Ctx) error {
 		c.Set("Content-Type", "application/json")
 		channelID := c.Query("channel_id")
 		if channelID == "" {
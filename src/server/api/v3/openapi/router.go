@@ -0,0 +1,184 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/SevenTV/ServerGo/src/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Route describes a route of the API, for its operation in the OpenAPI document
+type Route struct {
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	// The query parameters of the route. Path parameters are taken from the path, unless described here
+	Params []Param
+	// A value of the type of the response body
+	Response interface{}
+	// The error statuses the route may respond with, other than 500
+	Errors []int
+	// Whether the route is paginated by cursor, setting the X-Collection-Size and Link headers
+	Paginated bool
+}
+
+type Param struct {
+	Name        string
+	Description string
+	// "query" if empty
+	In       string
+	Required bool
+	// "string" if empty
+	Type string
+	Enum []string
+}
+
+var pathParamRegex = regexp.MustCompile(`:([A-Za-z0-9_]+)\??`)
+
+// Router registers routes on a fiber router, along with their operations in an OpenAPI document
+type Router struct {
+	router fiber.Router
+	// The path of the router, relative to the server URL
+	prefix    string
+	doc       *Document
+	errorBody *Schema
+}
+
+// NewRouter creates a router documenting its routes, relative to the URL where the fiber router is mounted.
+// Error responses are described by the type of errorBody
+func NewRouter(router fiber.Router, serverURL string, info Info, errorBody interface{}) *Router {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Servers: []Server{{URL: serverURL}},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+		},
+	}
+
+	return &Router{
+		router:    router,
+		doc:       doc,
+		errorBody: doc.SchemaOf(errorBody),
+	}
+}
+
+// Document returns the OpenAPI document of the routes registered so far
+func (r *Router) Document() *Document {
+	return r.doc
+}
+
+// Group creates a router for the routes under a path
+func (r *Router) Group(path string, handlers ...fiber.Handler) *Router {
+	return &Router{
+		router:    r.router.Group(path, handlers...),
+		prefix:    r.prefix + path,
+		doc:       r.doc,
+		errorBody: r.errorBody,
+	}
+}
+
+func (r *Router) Get(path string, route Route, handlers ...fiber.Handler) {
+	r.Handle(fiber.MethodGet, path, route, handlers...)
+}
+
+// Handle registers the handlers of a route and documents it.
+// Panics if the route is not described well enough to be documented, as this is a programming error
+func (r *Router) Handle(method string, path string, route Route, handlers ...fiber.Handler) {
+	if route.ID == "" || route.Response == nil {
+		panic(fmt.Sprintf("openapi: %s %s must have an ID and a response", method, r.prefix+path))
+	}
+	for _, item := range r.doc.Paths {
+		for _, op := range item {
+			if op.OperationID == route.ID {
+				panic(fmt.Sprintf("openapi: duplicate operation ID %s", route.ID))
+			}
+		}
+	}
+
+	op := &Operation{
+		OperationID: route.ID,
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        route.Tags,
+		Responses:   map[string]Response{},
+	}
+
+	// Path parameters, in the OpenAPI {name} syntax
+	described := map[string]bool{}
+	for _, p := range route.Params {
+		described[p.Name] = true
+	}
+	for _, m := range pathParamRegex.FindAllStringSubmatch(path, -1) {
+		if !described[m[1]] {
+			route.Params = append([]Param{{Name: m[1], In: "path"}}, route.Params...)
+		}
+	}
+	docPath := strings.TrimSuffix(r.prefix+pathParamRegex.ReplaceAllString(path, "{$1}"), "/")
+
+	for _, p := range route.Params {
+		in := p.In
+		if in == "" {
+			in = "query"
+		}
+		t := p.Type
+		if t == "" {
+			t = "string"
+		}
+		if in == "path" && !strings.Contains(docPath, "{"+p.Name+"}") {
+			panic(fmt.Sprintf("openapi: %s %s has no path parameter %s", method, docPath, p.Name))
+		}
+
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        p.Name,
+			In:          in,
+			Description: p.Description,
+			Required:    p.Required || in == "path",
+			Schema:      &Schema{Type: t, Enum: p.Enum},
+		})
+	}
+
+	success := Response{
+		Description: "OK",
+		Content: map[string]MediaType{
+			fiber.MIMEApplicationJSON: {Schema: r.doc.SchemaOf(route.Response)},
+		},
+	}
+	if route.Paginated {
+		op.Parameters = append(op.Parameters,
+			&Parameter{Name: "limit", In: "query", Description: "The amount of items in the page", Schema: &Schema{Type: "integer", Minimum: utils.Int64Pointer(1), Maximum: utils.Int64Pointer(150)}},
+			&Parameter{Name: "after", In: "query", Description: "The cursor of the previous page, to get the page after it", Schema: &Schema{Type: "string"}},
+		)
+		success.Headers = map[string]Header{
+			"X-Collection-Size": {Description: "The amount of items in all pages", Schema: &Schema{Type: "integer"}},
+			"Link":              {Description: `The URL of the next page with rel="next", if there is one`, Schema: &Schema{Type: "string"}},
+		}
+	}
+	op.Responses["200"] = success
+
+	for _, status := range append(route.Errors, fiber.StatusInternalServerError) {
+		op.Responses[fmt.Sprint(status)] = Response{
+			Description: http.StatusText(status),
+			Content: map[string]MediaType{
+				fiber.MIMEApplicationJSON: {Schema: r.errorBody},
+			},
+		}
+	}
+
+	item, ok := r.doc.Paths[docPath]
+	if !ok {
+		item = PathItem{}
+		r.doc.Paths[docPath] = item
+	}
+	if _, ok := item[strings.ToLower(method)]; ok {
+		panic(fmt.Sprintf("openapi: duplicate route %s %s", method, docPath))
+	}
+	item[strings.ToLower(method)] = op
+
+	r.router.Add(method, path, handlers...)
+}
@@ -0,0 +1,192 @@
+package v3
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/cosmetics"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/emotes"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/restutil"
+	"github.com/SevenTV/ServerGo/src/server/api/v2/rest/users"
+	"github.com/SevenTV/ServerGo/src/server/api/v3/openapi"
+	"github.com/SevenTV/ServerGo/src/server/middleware"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+func API(app fiber.Router) fiber.Router {
+	api := app.Group("/v3")
+	api.Use(cors.New(cors.Config{
+		AllowOrigins:  "*",
+		ExposeHeaders: "X-Collection-Size,Link,ETag,Last-Modified",
+		AllowMethods:  "GET",
+	}))
+	api.Use(func(c *fiber.Ctx) error {
+		c.Set("Content-Type", fiber.MIMEApplicationJSON)
+
+		return c.Next()
+	})
+	api.Use(errorEnvelope)
+	api.Use(middleware.UserAuthMiddleware(false))
+
+	r := openapi.NewRouter(api, "/v3", openapi.Info{
+		Title:       "7TV API",
+		Description: "The public REST API for interfacing with 7TV",
+		Version:     "3",
+	}, restutil.ErrorResponse{})
+	routes(r)
+
+	// The OpenAPI document, generated from the routes above
+	doc, err := json.Marshal(r.Document())
+	if err != nil {
+		panic(err)
+	}
+	api.Get("/openapi.json", func(c *fiber.Ctx) error {
+		c.Set("Cache-Control", "max-age=600")
+		return c.Send(doc)
+	})
+
+	api.Use(func(c *fiber.Ctx) error {
+		return restutil.ErrUnknownRoute().Send(c)
+	})
+
+	return api
+}
+
+func routes(r *openapi.Router) {
+	pageSortParams := []openapi.Param{
+		{Name: "sort_by", Description: "What to sort by, age by default", Enum: []string{"age", "popularity"}},
+		{Name: "sort_order", Description: "The direction to sort in, descending by default", Enum: []string{"asc", "desc"}},
+	}
+
+	// Emotes
+	emoteGroup := r.Group("/emotes")
+	emoteGroup.Get("/", openapi.Route{
+		ID:      "searchEmotes",
+		Summary: "Search emotes",
+		Tags:    []string{"emotes"},
+		Params: append([]openapi.Param{
+			{Name: "query", Description: "Text to search for in the names of emotes"},
+			{Name: "channel", Description: "Only emotes added by this channel"},
+			{Name: "global_state", Description: "Include, only or hide global emotes", Enum: []string{"include", "only", "hide"}},
+		}, pageSortParams...),
+		Response:  []restutil.EmoteResponse{},
+		Errors:    []int{fiber.StatusBadRequest, fiber.StatusTooManyRequests},
+		Paginated: true,
+	}, middleware.RateLimitMiddleware("search-emotes", 30, 6*time.Second), emotes.SearchEmotesHandler)
+	emoteGroup.Get("/global", openapi.Route{
+		ID:       "getGlobalEmotes",
+		Summary:  "Get the global emotes",
+		Tags:     []string{"emotes"},
+		Response: []restutil.EmoteResponse{},
+		Errors:   []int{fiber.StatusTooManyRequests},
+	}, middleware.RateLimitMiddleware("get-global-emotes", 25, 16*time.Second), emotes.GetGlobalEmotesHandler)
+	emoteGroup.Get("/:emote", openapi.Route{
+		ID:       "getEmote",
+		Summary:  "Get an emote",
+		Tags:     []string{"emotes"},
+		Response: restutil.EmoteResponse{},
+		Errors:   []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusTooManyRequests},
+	}, middleware.RateLimitMiddleware("get-emote", 30, 6*time.Second), emotes.GetEmoteHandler)
+	emoteGroup.Get("/:emote/status", openapi.Route{
+		ID:          "getEmoteStatus",
+		Summary:     "Get the processing status of an emote",
+		Description: "Not cached, for clients waiting for an upload to be processed",
+		Tags:        []string{"emotes"},
+		Response:    emotes.EmoteStatusResponse{},
+		Errors:      []int{fiber.StatusBadRequest, fiber.StatusNotFound, fiber.StatusTooManyRequests},
+	}, middleware.RateLimitMiddleware("get-emote-status", 60, 6*time.Second), emotes.GetEmoteStatusHandler)
+	emoteGroup.Get("/:emote/channels", openapi.Route{
+		ID:        "getEmoteChannels",
+		Summary:   "Get the channels which added an emote, highest role first",
+		Tags:      []string{"emotes"},
+		Response:  []restutil.UserResponse{},
+		Errors:    []int{fiber.StatusBadRequest, fiber.StatusTooManyRequests},
+		Paginated: true,
+	}, middleware.RateLimitMiddleware("get-emote-channels", 30, 6*time.Second), emotes.GetEmoteChannelsHandler)
+
+	// Users
+	userGroup := r.Group("/users")
+	userGroup.Get("/:user", openapi.Route{
+		ID:       "getUser",
+		Summary:  "Get a user by ID, login or Twitch ID",
+		Tags:     []string{"users"},
+		Response: restutil.UserResponse{},
+		Errors:   []int{fiber.StatusNotFound},
+	}, users.GetUserHandler)
+	userGroup.Get("/:user/emotes", openapi.Route{
+		ID:       "getChannelEmotes",
+		Summary:  "Get the emotes of a channel",
+		Tags:     []string{"users", "emotes"},
+		Response: []restutil.EmoteResponse{},
+		Errors:   []int{fiber.StatusNotFound, fiber.StatusTooManyRequests},
+	}, middleware.RateLimitMiddleware("get-user-emotes", 100, 9*time.Second), users.GetChannelEmotesHandler)
+	userGroup.Get("/:user/editors", openapi.Route{
+		ID:       "getUserEditors",
+		Summary:  "Get the editors of a channel",
+		Tags:     []string{"users"},
+		Response: []restutil.UserResponse{},
+		Errors:   []int{fiber.StatusNotFound, fiber.StatusTooManyRequests},
+	}, middleware.RateLimitMiddleware("get-user-editors", 30, 6*time.Second), users.GetUserEditorsHandler)
+
+	r.Get("/channels/emotes", openapi.Route{
+		ID:          "getBulkChannelEmotes",
+		Summary:     "Get the emotes of several channels at once",
+		Description: "Channels are mapped by the identifiers they were requested with. Unknown channels are omitted",
+		Tags:        []string{"users", "emotes"},
+		Params: []openapi.Param{
+			{Name: "channels", Description: "Comma separated Twitch IDs, logins or YouTube IDs of the channels", Required: true},
+		},
+		Response: map[string][]restutil.EmoteResponse{},
+		Errors:   []int{fiber.StatusBadRequest, fiber.StatusTooManyRequests},
+	}, middleware.RateLimitMiddleware("get-bulk-channel-emotes", 20, 9*time.Second), users.GetBulkChannelEmotesHandler)
+
+	// Cosmetics
+	r.Get("/badges", openapi.Route{
+		ID:      "getBadges",
+		Summary: "Get all badges and the users who can show them",
+		Tags:    []string{"cosmetics"},
+		Params: []openapi.Param{
+			{Name: "user_identifier", Description: "How users are identified", Required: true, Enum: []string{"object_id", "twitch_id", "login"}},
+		},
+		Response: cosmetics.GetBadgesResult{},
+		Errors:   []int{fiber.StatusBadRequest},
+	}, cosmetics.GetBadgesHandler)
+	r.Get("/cosmetics/avatars", openapi.Route{
+		ID:          "getAvatars",
+		Summary:     "Get the custom avatars of users",
+		Description: "The URLs of avatars, mapped to users by the given key",
+		Tags:        []string{"cosmetics"},
+		Params: []openapi.Param{
+			{Name: "map_to", Description: "What to map avatars to, the hash of the user's Twitch avatar by default", Enum: []string{"hash", "twitch_id", "object_id", "login"}},
+		},
+		Response: map[string]string{},
+	}, cosmetics.GetAvatarsHandler)
+}
+
+// Send errors from middlewares and fiber in the same envelope as the handlers
+func errorEnvelope(c *fiber.Ctx) error {
+	if err := c.Next(); err != nil {
+		status, message := fiber.StatusInternalServerError, err.Error()
+		if e, ok := err.(*fiber.Error); ok {
+			status = e.Code
+		}
+		return (&restutil.ErrorResponse{Status: status, Message: message}).Send(c)
+	}
+
+	status := c.Response().StatusCode()
+	if status < 400 {
+		return nil
+	}
+
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(c.Response().Body(), &body); err != nil || body.Message != "" {
+		return nil
+	}
+
+	// The middlewares respond with {"status", "error"}
+	return (&restutil.ErrorResponse{Status: status, Message: body.Error}).Send(c)
+}
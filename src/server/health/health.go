@@ -3,68 +3,207 @@ package health
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/SevenTV/ServerGo/src/auth"
+	"github.com/SevenTV/ServerGo/src/configure"
 	"github.com/SevenTV/ServerGo/src/discord"
 	"github.com/SevenTV/ServerGo/src/mongo"
+	"github.com/SevenTV/ServerGo/src/ratelimit"
 	"github.com/SevenTV/ServerGo/src/redis"
+	"github.com/SevenTV/ServerGo/src/storage"
 	"github.com/gofiber/fiber/v2"
 
 	log "github.com/sirupsen/logrus"
 )
 
-func Health(app fiber.Router) {
-	downedServices := map[string]bool{
-		"redis": false,
-		"mongo": false,
+// A dependency of the server, checked periodically in the background
+type dependency struct {
+	Name string `json:"name"`
+	// Whether the server can't serve requests while this dependency is down
+	Critical bool `json:"critical"`
+
+	OK          bool       `json:"ok"`
+	Latency     float64    `json:"latency_ms"`
+	CheckedAt   *time.Time `json:"checked_at"`
+	LastError   *string    `json:"last_error"`
+	LastErrorAt *time.Time `json:"last_error_at"`
+
+	check func(ctx context.Context) error
+	// Check no more often than this, for dependencies which are expensive to check
+	every time.Duration
+}
+
+var (
+	mtx          = sync.RWMutex{}
+	dependencies = []*dependency{
+		{Name: "redis", Critical: true, check: func(ctx context.Context) error {
+			return redis.Client.Ping(ctx).Err()
+		}},
+		{Name: "mongo", Critical: true, check: func(ctx context.Context) error {
+			return mongo.Database.Client().Ping(ctx, nil)
+		}},
+		{Name: "storage", check: func(ctx context.Context) error {
+			return storage.Ping(ctx, configure.Config.GetString("aws_cdn_bucket"))
+		}},
+		{Name: "twitch", check: auth.Validate, every: time.Minute * 5},
+		{Name: "lua_scripts", check: redis.CheckScripts},
 	}
 
-	mtx := sync.Mutex{}
-	app.Get("/health", func(c *fiber.Ctx) error {
-		mtx.Lock()
-		defer mtx.Unlock()
-
-		isDown := false
-
-		redisCtx, cancel := context.WithTimeout(c.Context(), time.Second*10)
-		defer cancel()
-		// CHECK REDIS
-		if ping := redis.Client.Ping(redisCtx).Val(); ping == "" {
-			log.Error("health, REDIS IS DOWN")
-			isDown = true
-			if down := downedServices["redis"]; !down {
-				go discord.SendServiceDown("redis")
-				downedServices["redis"] = true
-			}
-		} else {
-			if down := downedServices["redis"]; down {
-				go discord.SendServiceRestored("redis")
-				downedServices["redis"] = false
-			}
+	draining int32
+)
+
+// Drain makes the server report as not ready, so that load balancers stop routing to it before it shuts down
+func Drain() {
+	atomic.StoreInt32(&draining, 1)
+}
+
+func isDraining() bool {
+	return atomic.LoadInt32(&draining) == 1
+}
+
+// Whether the server can serve requests, and if not, why
+func isReady() (bool, string) {
+	if isDraining() {
+		return false, "draining"
+	}
+
+	mtx.RLock()
+	defer mtx.RUnlock()
+	for _, dep := range dependencies {
+		if !dep.Critical || dep.OK {
+			continue
+		}
+		if dep.CheckedAt == nil {
+			return false, "starting"
 		}
+		return false, dep.Name + " is down"
+	}
+
+	return true, ""
+}
+
+// Check all dependencies at once, alerting when a critical one goes down or is restored
+func checkAll(ctx context.Context, timeout time.Duration) {
+	wg := sync.WaitGroup{}
+	for _, dep := range dependencies {
+		mtx.RLock()
+		skip := dep.every > 0 && dep.CheckedAt != nil && time.Since(*dep.CheckedAt) < dep.every
+		mtx.RUnlock()
+		if skip {
+			continue
+		}
+
+		wg.Add(1)
+		go func(dep *dependency) {
+			defer wg.Done()
 
-		// CHECK MONGO
-		mongoCtx, cancel := context.WithTimeout(c.Context(), time.Second*10)
-		defer cancel()
-		if err := mongo.Database.Client().Ping(mongoCtx, nil); err != nil {
-			log.Error("health, MONGO IS DOWN")
-			isDown = true
-			if down := downedServices["mongo"]; !down {
-				go discord.SendServiceDown("mongo")
-				downedServices["mongo"] = true
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := dep.check(checkCtx)
+			now := time.Now()
+
+			mtx.Lock()
+			wasOK, checked := dep.OK, dep.CheckedAt != nil
+			dep.OK = err == nil
+			dep.Latency = float64(now.Sub(start).Microseconds()) / 1000
+			dep.CheckedAt = &now
+			if err != nil {
+				msg := err.Error()
+				dep.LastError = &msg
+				dep.LastErrorAt = &now
 			}
-		} else {
-			if down := downedServices["mongo"]; down {
-				go discord.SendServiceRestored("mongo")
-				downedServices["mongo"] = false
+			mtx.Unlock()
+
+			if err != nil && (wasOK || !checked) {
+				log.WithError(err).WithField("dependency", dep.Name).Error("health, dependency is down")
+				if dep.Critical {
+					discord.Go(func() { discord.SendServiceDown(dep.Name) })
+				}
+			} else if err == nil && !wasOK && checked {
+				log.WithField("dependency", dep.Name).Info("health, dependency restored")
+				if dep.Critical {
+					discord.Go(func() { discord.SendServiceRestored(dep.Name) })
+				}
 			}
+		}(dep)
+	}
+	wg.Wait()
+}
+
+func Health(app fiber.Router) {
+	interval := configure.Config.GetDuration("health.interval")
+	if interval <= 0 {
+		interval = time.Second * 10
+	}
+	timeout := configure.Config.GetDuration("health.timeout")
+	if timeout <= 0 {
+		timeout = time.Second * 5
+	}
+
+	// Probes only read the result of the last checks, so they answer immediately
+	go func() {
+		ctx := context.Background()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			checkAll(ctx, timeout)
+			<-ticker.C
 		}
+	}()
+
+	// Liveness: the process is up and serving requests
+	app.Get("/health/live", func(c *fiber.Ctx) error {
+		return c.Status(200).SendString("OK")
+	})
 
-		if isDown {
-			return c.SendStatus(503)
+	// Readiness: the critical dependencies are up and the server is not shutting down
+	ready := func(c *fiber.Ctx) error {
+		if ok, reason := isReady(); !ok {
+			return c.Status(503).SendString(reason)
 		}
 
 		return c.Status(200).SendString("OK")
+	}
+	app.Get("/health/ready", ready)
+	app.Get("/health", ready)
+
+	// Detail: whether the server is ready or draining. Why a dependency is down is only told on the internal listener
+	app.Get("/health/detail", func(c *fiber.Ctx) error {
+		ok, _ := isReady()
+
+		status := 200
+		if !ok {
+			status = 503
+		}
+		return c.Status(status).JSON(&fiber.Map{
+			"ready":    ok,
+			"draining": isDraining(),
+		})
 	})
+}
+
+// Internal serves the state of every dependency, and whether rate limits are counted locally while redis is unhealthy.
+// Dependency errors can tell about the infrastructure, so this is only for the internal listener
+func Internal(app fiber.Router) {
+	app.Get("/health/detail", func(c *fiber.Ctx) error {
+		ok, _ := isReady()
+
+		mtx.RLock()
+		defer mtx.RUnlock()
 
+		status := 200
+		if !ok {
+			status = 503
+		}
+		return c.Status(status).JSON(&fiber.Map{
+			"ready":        ok,
+			"draining":     isDraining(),
+			"dependencies": dependencies,
+			"rate_limiter": ratelimit.GetState(),
+		})
+	})
 }
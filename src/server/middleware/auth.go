@@ -24,8 +24,13 @@ type PayloadJWT struct {
 
 func UserAuthMiddleware(required bool) func(c *fiber.Ctx) error {
 	return func(c *fiber.Ctx) error {
+		// Already authenticated by a group the route is part of
+		if _, ok := c.Locals("user").(*datastructure.User); ok {
+			return c.Next()
+		}
+
 		auth := strings.Split(c.Get("Authorization"), " ")
-		if len(auth) != 2 && auth[0] != "Bearer" {
+		if len(auth) != 2 || auth[0] != "Bearer" {
 			if !required {
 				return c.Next()
 			}
@@ -35,50 +40,76 @@ func UserAuthMiddleware(required bool) func(c *fiber.Ctx) error {
 			})
 		}
 
-		token := strings.Split(auth[1], ".")
+		var query bson.M
+		var accessToken *datastructure.AccessToken
+		if strings.HasPrefix(auth[1], datastructure.AccessTokenPrefix) {
+			// Personal access token
+			token, err := actions.AccessTokens.Authenticate(c.Context(), auth[1])
+			if err != nil {
+				if err != mongo.ErrNoDocuments {
+					log.WithError(err).Error("mongo")
+				}
+				if !required {
+					return c.Next()
+				}
+				return c.Status(403).JSON(&fiber.Map{
+					"status": 403,
+					"error":  "Invalid Token",
+				})
+			}
 
-		if len(token) != 3 {
-			if !required {
-				return c.Next()
+			accessToken = token
+			query = bson.M{
+				"_id": token.UserID,
 			}
-			return c.Status(403).JSON(&fiber.Map{
-				"status": 403,
-				"error":  "Invalid Token",
-			})
-		}
+		} else {
+			// Session token
+			token := strings.Split(auth[1], ".")
 
-		pl := &PayloadJWT{}
-		if err := jwt.Verify(token, pl); err != nil {
-			log.WithError(err).Error("jwt")
-			if !required {
-				return c.Next()
+			if len(token) != 3 {
+				if !required {
+					return c.Next()
+				}
+				return c.Status(403).JSON(&fiber.Map{
+					"status": 403,
+					"error":  "Invalid Token",
+				})
 			}
-			return c.Status(403).JSON(&fiber.Map{
-				"status": 403,
-				"error":  "Invalid Token",
-			})
-		}
 
-		if pl.CreatedAt.Before(time.Now().Add(-time.Hour * 24 * 60)) {
-			if !required {
-				return c.Next()
+			pl := &PayloadJWT{}
+			if err := jwt.Verify(token, pl); err != nil {
+				log.WithError(err).Error("jwt")
+				if !required {
+					return c.Next()
+				}
+				return c.Status(403).JSON(&fiber.Map{
+					"status": 403,
+					"error":  "Invalid Token",
+				})
 			}
-			return c.Status(403).JSON(&fiber.Map{
-				"status": 403,
-				"error":  "Access Token Expired",
-			})
-		}
 
-		query := bson.M{
-			"_id": pl.ID,
-		}
+			if pl.CreatedAt.Before(time.Now().Add(-time.Hour * 24 * 60)) {
+				if !required {
+					return c.Next()
+				}
+				return c.Status(403).JSON(&fiber.Map{
+					"status": 403,
+					"error":  "Access Token Expired",
+				})
+			}
 
-		if pl.TokenVersion == "" {
-			query["token_version"] = bson.M{
-				"$exists": false,
+			query = bson.M{
+				"_id": pl.ID,
 			}
-		} else {
-			query["token_version"] = pl.TokenVersion
+
+			if pl.TokenVersion == "" {
+				query["token_version"] = bson.M{
+					"$exists": false,
+				}
+			} else {
+				query["token_version"] = pl.TokenVersion
+			}
+
 		}
 
 		res := mongo.Collection(mongo.CollectionNameUsers).FindOne(c.Context(), query)
@@ -144,6 +175,39 @@ func UserAuthMiddleware(required bool) func(c *fiber.Ctx) error {
 		user.Role = &role
 
 		c.Locals("user", user)
+		if accessToken != nil {
+			c.Locals("access_token", accessToken)
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireScope rejects requests made with a personal access token which was not granted a scope.
+// Requests made with a session token are let through
+func RequireScope(scope datastructure.AccessTokenScope) func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if token, ok := c.Locals("access_token").(*datastructure.AccessToken); ok && !token.HasScope(scope) {
+			return c.Status(403).JSON(&fiber.Map{
+				"status": 403,
+				"error":  "Missing Scope",
+				"scope":  scope,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireSession rejects requests made with a personal access token
+func RequireSession() func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		if _, ok := c.Locals("access_token").(*datastructure.AccessToken); ok {
+			return c.Status(403).JSON(&fiber.Map{
+				"status": 403,
+				"error":  "Session Required",
+			})
+		}
 
 		return c.Next()
 	}
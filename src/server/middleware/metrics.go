@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records the count and latency of requests, by the route which served them
+func Metrics() func(c *fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		// The route pattern rather than the path, so that IDs don't end up as labels
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		if err != nil {
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			} else {
+				status = 500
+			}
+		}
+		if status == 404 && c.Route().Method == "USE" {
+			route = "unmatched" // Fell through to the catch-all handler
+		}
+
+		labels := []string{route, c.Method(), strconv.Itoa(status)}
+		metrics.HttpRequests.WithLabelValues(labels...).Inc()
+		metrics.HttpRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
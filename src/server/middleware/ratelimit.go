@@ -1,85 +1,40 @@
 package middleware
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/metrics"
 	"github.com/SevenTV/ServerGo/src/mongo/datastructure"
-	"github.com/SevenTV/ServerGo/src/redis"
-	"github.com/SevenTV/ServerGo/src/utils"
+	"github.com/SevenTV/ServerGo/src/ratelimit"
 	"github.com/gofiber/fiber/v2"
 	log "github.com/sirupsen/logrus"
 )
 
-type RateLimiter struct {
-	RedisKey string
-
-	Identifier string
-	Limit      int32
-	Remaining  int32
-	Reset      time.Duration
-}
-
+// RateLimitMiddleware limits requests by the policy named by the tag.
+// The limit and duration are its burst window when the policy isn't configured, or doesn't set one
 func RateLimitMiddleware(tag string, limit int32, duration time.Duration) func(c *fiber.Ctx) error {
-	return func(c *fiber.Ctx) error {
-		// Get identifier
-		// It is one of: Authorized User ID, Client IP Address
-		var identifier string
-		if c.Locals("user") != nil {
-			user := c.Locals("user").(*datastructure.User)
-			identifier = user.ID.Hex()
-		} else if len(c.IPs()) > 0 {
-			identifier = c.IPs()[0]
-		} else {
-			identifier = c.IP()
-		}
-
-		// Create hash of identifier + route tag
-		h := sha1.New()
-		h.Write(utils.S2B(identifier))
-		h.Write(utils.S2B(tag))
-
-		// Remaining
-		remaining := int64(limit)
-		ttl := int64(0)
+	ratelimit.Register(tag, ratelimit.Window{Limit: limit, Period: duration})
 
-		// Ensure script
-		scriptExists, _ := redis.Client.ScriptExists(c.Context(), redis.RateLimitScriptSHA1).Result()
-		if !scriptExists[0] {
-			if err := redis.ReloadScripts(); err != nil {
-				return err
-			}
-			log.Info("ratelimit, redis: reloaded scripts")
-		}
-
-		// Create RateLimiter instance
-		redisKey := hex.EncodeToString(h.Sum(nil))
-		if result, err := redis.Client.EvalSha(c.Context(), redis.RateLimitScriptSHA1, []string{}, redisKey, duration.Seconds(), limit, 1).Result(); err != nil {
+	return func(c *fiber.Ctx) error {
+		result, err := ratelimit.Consume(c.Context(), tag, GetRateLimitCaller(c))
+		if err != nil {
 			log.WithError(err).Error("ratelimit")
 			c.Set("X-RateLimit-Error", err.Error())
 			return c.Next()
-		} else {
-			a := make([]int64, 3)
-			for i, v := range result.([]interface{}) {
-				val := v.(int64)
-				a[i] = val
-			}
-
-			remaining -= a[0]
-			ttl = a[1]
 		}
 
 		// Apply rate limit headers
-		c.Set("X-RateLimit-Limit", strconv.Itoa(int(limit)))
-		c.Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining)))
-
-		c.Set("X-RateLimit-Reset", fmt.Sprint(ttl))
+		c.Set("X-RateLimit-Limit", strconv.Itoa(int(result.Limit)))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(int(result.Remaining)))
+		c.Set("X-RateLimit-Reset", fmt.Sprint(result.Reset))
+		c.Set("X-RateLimit-Policy", tag)
 
 		// 429 Too Many Requests?
-		if remaining < 1 {
+		if !result.Allowed {
+			metrics.RateLimitRejections.WithLabelValues(tag).Inc()
 			return c.Status(fiber.StatusTooManyRequests).JSON(&fiber.Map{
 				"status": 429,
 				"error":  "You are being rate limited",
@@ -90,7 +45,53 @@ func RateLimitMiddleware(tag string, limit int32, duration time.Duration) func(c
 	}
 }
 
-// type rateLimitScriptReply struct {
-// 	Count int `json:"count"`
-// 	TTL   int `json:"ttl"`
-// }
+// RouteRateLimitMiddleware limits requests like RateLimitMiddleware, with the limit and duration in milliseconds
+// set by the "limits.route.<tag>" config option, which is applied again whenever it changes
+func RouteRateLimitMiddleware(tag string) func(c *fiber.Ctx) error {
+	key := "limits.route." + tag
+	window := func() (ratelimit.Window, error) {
+		rl := configure.Config.GetIntSlice(key)
+		if len(rl) != 2 {
+			return ratelimit.Window{}, fmt.Errorf("%s: must be [limit, duration in milliseconds]", key)
+		}
+
+		return ratelimit.Window{Limit: int32(rl[0]), Period: time.Millisecond * time.Duration(rl[1])}, nil
+	}
+
+	w, err := window()
+	if err != nil {
+		log.WithError(err).Fatal("config")
+	}
+	configure.Subscribe(configure.Subscription{
+		Keys: []string{key},
+		OnChange: func() {
+			if w, err := window(); err == nil {
+				ratelimit.Register(tag, w)
+			}
+		},
+	})
+
+	return RateLimitMiddleware(tag, w.Limit, w.Period)
+}
+
+// GetRateLimitCaller identifies who a request is counted against.
+// It is one of: Authorized User ID, Client IP Address. Requests made with any of a user's access tokens count against
+// the user, the token only choosing their tier, so that holding more tokens doesn't raise their limits
+func GetRateLimitCaller(c *fiber.Ctx) ratelimit.Caller {
+	caller := ratelimit.Caller{}
+	if user, ok := c.Locals("user").(*datastructure.User); ok && user != nil {
+		caller.Identifier = user.ID.Hex()
+		if user.Role != nil {
+			caller.RoleID = user.Role.ID.Hex()
+		}
+		if token, ok := c.Locals("access_token").(*datastructure.AccessToken); ok && token != nil {
+			caller.TokenID = token.ID.Hex()
+		}
+	} else if len(c.IPs()) > 0 {
+		caller.Identifier = c.IPs()[0]
+	} else {
+		caller.Identifier = c.IP()
+	}
+
+	return caller
+}
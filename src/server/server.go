@@ -1,23 +1,34 @@
 package server
 
 import (
+	"context"
 	"net"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/SevenTV/ServerGo/src/jwt"
+	"github.com/SevenTV/ServerGo/src/metrics"
 	apiv2 "github.com/SevenTV/ServerGo/src/server/api/v2"
+	apiv3 "github.com/SevenTV/ServerGo/src/server/api/v3"
 	"github.com/SevenTV/ServerGo/src/server/health"
 	"github.com/SevenTV/ServerGo/src/server/middleware"
+	"github.com/SevenTV/ServerGo/src/storage"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/SevenTV/ServerGo/src/configure"
 	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
 )
 
 type Server struct {
 	app      *fiber.App
 	listener net.Listener
+	// Serves the internal routes, on a listener of its own. Nil if there is none
+	internal *fiber.App
+
+	// Connections waiting for their next request, closed on shutdown
+	idle sync.Map
 }
 
 func New() *Server {
@@ -36,7 +47,18 @@ func New() *Server {
 		listener: l,
 	}
 
+	// Track idle connections, as shutdown would otherwise wait for keep-alive clients to hang up
+	server.app.Server().CloseOnShutdown = true
+	server.app.Server().ConnState = func(c net.Conn, state fasthttp.ConnState) {
+		if state == fasthttp.StateIdle {
+			server.idle.Store(c, true)
+		} else {
+			server.idle.Delete(c)
+		}
+	}
+
 	server.app.Use(middleware.Logger())
+	server.app.Use(middleware.Metrics())
 
 	server.app.Use(func(c *fiber.Ctx) error {
 		c.Set("X-Node-Name", configure.NodeName)
@@ -79,6 +101,12 @@ func New() *Server {
 
 	health.Health(server.app)
 	apiv2.API(server.app)
+	apiv3.API(server.app)
+
+	// Serve the CDN from disk when files are stored locally
+	if local, ok := storage.GetBackend().(*storage.Local); ok && configure.Config.GetBool("storage.local.serve") {
+		server.app.Get("/cdn/*", local.Handler(configure.Config.GetString("aws_cdn_bucket")))
+	}
 
 	server.app.Use(func(c *fiber.Ctx) error {
 		return c.Status(404).JSON(&fiber.Map{
@@ -94,9 +122,74 @@ func New() *Server {
 		}
 	}()
 
+	if uri := configure.Config.GetString("internal_conn_uri"); uri != "" {
+		server.internal = newInternal(uri)
+	}
+
 	return server
 }
 
-func (s *Server) Shutdown() error {
-	return s.listener.Close()
+// Start the server of the internal routes, such as the metrics, which are kept off the public listener
+func newInternal(uri string) *fiber.App {
+	l, err := net.Listen(configure.Config.GetString("conn_type"), uri)
+	if err != nil {
+		log.Fatalf("failed to start listner for internal http server, err=%v", err)
+		return nil
+	}
+
+	app := fiber.New(fiber.Config{
+		DisableStartupMessage: true,
+		IdleTimeout:           time.Second * 30,
+	})
+	health.Internal(app)
+	metrics.Metrics(app)
+
+	go func() {
+		if err := app.Listener(l); err != nil {
+			log.WithError(err).Fatal("failed to start internal http server")
+		}
+	}()
+
+	return app
+}
+
+// Shutdown stops accepting connections and waits for the requests in flight to complete, until the context is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	// The internal routes are served until the end, so the drain can be watched
+	if s.internal != nil {
+		defer func() {
+			done := make(chan struct{})
+			go func() {
+				_ = s.internal.Shutdown()
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.app.Shutdown()
+	}()
+
+	ticker := time.NewTicker(time.Millisecond * 100)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			// Give up on the remaining connections, such as websockets
+			return ctx.Err()
+		case <-ticker.C:
+			s.idle.Range(func(key, value interface{}) bool {
+				_ = key.(net.Conn).Close()
+				s.idle.Delete(key)
+				return true
+			})
+		}
+	}
 }
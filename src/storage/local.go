@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// The suffix of the file holding a stored file's content type
+const contentTypeSuffix = ".content-type"
+
+// Local stores files in a directory, as <path>/<bucket>/<key>
+type Local struct {
+	path string
+}
+
+func NewLocal(path string) *Local {
+	if path == "" {
+		path = "./storage"
+	}
+
+	return &Local{path}
+}
+
+// Get the location of a key on disk. Keys can't escape the bucket's directory
+func (l *Local) filePath(bucket, key string) string {
+	return filepath.Join(l.path, bucket, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (l *Local) UploadFile(bucket, key string, body []byte, contentType *string) error {
+	p := l.filePath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to upload file, %v", err)
+	}
+
+	if err := os.WriteFile(p, body, 0644); err != nil {
+		return fmt.Errorf("failed to upload file, %v", err)
+	}
+	if contentType != nil {
+		if err := os.WriteFile(p+contentTypeSuffix, []byte(*contentType), 0644); err != nil {
+			return fmt.Errorf("failed to upload file, %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Local) UploadPrivateFile(bucket, key string, body []byte) error {
+	// Keys which are kept private are never served by the handler
+	return l.UploadFile(bucket, key, body, nil)
+}
+
+func (l *Local) DownloadFile(bucket, key string) ([]byte, error) {
+	b, err := os.ReadFile(l.filePath(bucket, key))
+	if err != nil {
+		return nil, fmt.Errorf("unable to download object %q from bucket %q, %v", key, bucket, err)
+	}
+
+	return b, nil
+}
+
+func (l *Local) Expire(bucket, key string, number int) error {
+	if err := l.move(bucket, fmt.Sprintf("%s/%vx", key, number), fmt.Sprintf("deleted/%s/%vx", key, number)); err != nil {
+		return fmt.Errorf("unable to expire object %q from bucket %q, %v", key, bucket, err)
+	}
+
+	return nil
+}
+
+func (l *Local) Unexpire(bucket, key string, number int) error {
+	if err := l.move(bucket, fmt.Sprintf("deleted/%s/%vx", key, number), fmt.Sprintf("%s/%vx", key, number)); err != nil {
+		return fmt.Errorf("unable to unexpire object %q from bucket %q, %v", key, bucket, err)
+	}
+
+	return nil
+}
+
+func (l *Local) DeleteFile(bucket, key string, wait bool) error {
+	p := l.filePath(bucket, key)
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to delete object %q from bucket %q, %v", key, bucket, err)
+	}
+	_ = os.Remove(p + contentTypeSuffix)
+
+	return nil
+}
+
+func (l *Local) Ping(ctx context.Context, bucket string) error {
+	if info, err := os.Stat(l.path); err != nil {
+		return fmt.Errorf("unable to reach bucket %q, %v", bucket, err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("unable to reach bucket %q, %s is not a directory", bucket, l.path)
+	}
+
+	return nil
+}
+
+// Move a file and its content type to another key
+func (l *Local) move(bucket, from, to string) error {
+	src := l.filePath(bucket, from)
+	dst := l.filePath(bucket, to)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+	if err := os.Rename(src+contentTypeSuffix, dst+contentTypeSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Serve the files of a bucket. Expired files and uploads waiting to be processed are not served
+func (l *Local) Handler(bucket string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := path.Clean("/" + c.Params("*"))
+		if strings.HasPrefix(key, "/deleted/") || strings.HasPrefix(key, "/"+UploadsPrefix) || strings.HasSuffix(key, contentTypeSuffix) {
+			return fiber.ErrNotFound
+		}
+
+		p := l.filePath(bucket, key)
+		if info, err := os.Stat(p); err != nil || info.IsDir() {
+			return fiber.ErrNotFound
+		}
+
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		if contentType, err := os.ReadFile(p + contentTypeSuffix); err == nil {
+			c.Set("Content-Type", string(contentType))
+		}
+		c.Set("Cache-Control", "public, max-age=15552000")
+
+		return c.Send(b)
+	}
+}
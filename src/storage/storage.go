@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/SevenTV/ServerGo/src/aws"
+	"github.com/SevenTV/ServerGo/src/configure"
+	"github.com/SevenTV/ServerGo/src/metrics"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// Storage is a backend for the files served on the CDN, such as emotes and profile pictures
+type Storage interface {
+	// Store a file, replacing any existing file with the same key
+	UploadFile(bucket, key string, body []byte, contentType *string) error
+	// Store a file out of public access, such as an upload waiting to be processed
+	UploadPrivateFile(bucket, key string, body []byte) error
+	// Read a stored file
+	DownloadFile(bucket, key string) ([]byte, error)
+	// Move the file at <key>/<number>x out of public access, to deleted/<key>/<number>x
+	Expire(bucket, key string, number int) error
+	// Restore a file which was expired
+	Unexpire(bucket, key string, number int) error
+	// Delete a file. If wait is true, return only once the file is gone
+	DeleteFile(bucket, key string, wait bool) error
+	// Check that a bucket can be reached
+	Ping(ctx context.Context, bucket string) error
+}
+
+// The prefix of the keys of original uploads, kept until they are processed
+const UploadsPrefix = "uploads/"
+
+var (
+	backendMtx = sync.RWMutex{}
+	// The backend selected by the "storage.backend" config option
+	backend Storage
+)
+
+func init() {
+	b, err := newBackend(configure.Config.Viper())
+	if err != nil {
+		log.WithError(err).Fatal("storage")
+	}
+	backend = b
+
+	// Switch credentials without a restart. The backend itself is only set up once, along with the route serving
+	// local files, as switching it would split the files between two backends
+	configure.Subscribe(configure.Subscription{
+		Keys: []string{"aws_akid", "aws_secret_key", "aws_session_token", "aws_region", "aws_endpoint"},
+		Validate: func(c *viper.Viper) error {
+			_, err := newBackend(c)
+			return err
+		},
+		OnChange: func() {
+			b, err := newBackend(configure.Config.Viper())
+			if err != nil {
+				log.WithError(err).Error("storage")
+				return
+			}
+
+			backendMtx.Lock()
+			backend = b
+			backendMtx.Unlock()
+		},
+	})
+}
+
+// Create the backend selected by a config
+func newBackend(c *viper.Viper) (Storage, error) {
+	switch name := c.GetString("storage.backend"); name {
+	case "local":
+		return NewLocal(c.GetString("storage.local.path")), nil
+	case "s3", "":
+		return aws.NewS3(c)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", name)
+	}
+}
+
+// GetBackend returns the backend currently in use
+func GetBackend() Storage {
+	backendMtx.RLock()
+	defer backendMtx.RUnlock()
+
+	return backend
+}
+
+func UploadFile(bucket, key string, body []byte, contentType *string) error {
+	start := time.Now()
+	err := GetBackend().UploadFile(bucket, key, body, contentType)
+	metrics.StorageUploadDuration.WithLabelValues(metrics.Result(err)).Observe(time.Since(start).Seconds())
+
+	return err
+}
+
+func UploadPrivateFile(bucket, key string, body []byte) error {
+	return GetBackend().UploadPrivateFile(bucket, key, body)
+}
+
+func DownloadFile(bucket, key string) ([]byte, error) {
+	return GetBackend().DownloadFile(bucket, key)
+}
+
+func Expire(bucket, key string, number int) error {
+	return GetBackend().Expire(bucket, key, number)
+}
+
+func Unexpire(bucket, key string, number int) error {
+	return GetBackend().Unexpire(bucket, key, number)
+}
+
+func DeleteFile(bucket, key string, wait bool) error {
+	return GetBackend().DeleteFile(bucket, key, wait)
+}
+
+func Ping(ctx context.Context, bucket string) error {
+	return GetBackend().Ping(ctx, bucket)
+}
@@ -186,5 +186,8 @@ func GetSizeRatio(og []float64, nw []float64) (int32, int32) {
 type Key string
 
 const UserKey = Key("user")
+const AccessTokenKey = Key("AccessToken")
+const QueryCostKey = Key("QueryCost")
+const LoadersKey = Key("Loaders")
 const RequestCtxKey = Key("RequestCtx")
 const AllRolesKey = Key("AllRoles")
@@ -10,6 +10,9 @@ var (
 	emoteNameRegex = regexp.MustCompile(`^[-_A-Za-z():0-9]{2,100}$`)
 	emoteTagRegex  = regexp.MustCompile(`^[0-9a-z]{3,30}$`)
 
+	emoteSetNameRegex    = regexp.MustCompile(`^[-_A-Za-z():0-9 ]{1,32}$`)
+	accessTokenNameRegex = regexp.MustCompile(`^[-_.A-Za-z():0-9 ]{1,64}$`)
+
 //	ValidateEmoteTag = regexp.MustCompile(`^[\\w-]{2,100}$`)
 )
 
@@ -17,6 +20,14 @@ func ValidateEmoteName(name []byte) bool {
 	return emoteNameRegex.Match(name)
 }
 
+func ValidateEmoteSetName(name []byte) bool {
+	return emoteSetNameRegex.Match(name)
+}
+
+func ValidateAccessTokenName(name []byte) bool {
+	return accessTokenNameRegex.Match(name)
+}
+
 func ValidateEmoteTags(tags []string) (bool, string) {
 	for _, s := range tags {
 		if ok := emoteTagRegex.Match(utils.S2B(s)); !ok {